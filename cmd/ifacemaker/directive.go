@@ -0,0 +1,174 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+const generatePrefix = "//go:generate ifacemaker "
+
+// writeDirective adds a go:generate directive running ifacemaker with the
+// arguments to the Go file. The directive generating the same --output is
+// replaced, so a repeated run leaves a single one. The file of the package
+// is created if there is none.
+func writeDirective(fs afero.Fs, filename, packageName string, args []string) error {
+	code, err := afero.ReadFile(fs, filename)
+	if errors.Is(err, os.ErrNotExist) {
+		code, err = []byte("package "+packageName+"\n"), nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading the directive file: %v", err)
+	}
+
+	directive := generatePrefix + strings.Join(args, " ")
+	output := directiveOutput(args)
+
+	lines := strings.Split(string(code), "\n")
+	packageLine, lastDirective := -1, -1
+
+	for i, line := range lines {
+		if strings.HasPrefix(line, generatePrefix) {
+			if directiveOutput(strings.Fields(strings.TrimPrefix(line, generatePrefix))) == output {
+				lines[i] = directive
+				return writeFileAtomically(fs, filename, []byte(strings.Join(lines, "\n")))
+			}
+			lastDirective = i
+		}
+		if packageLine < 0 && strings.HasPrefix(line, "package ") {
+			packageLine = i
+		}
+	}
+
+	// next to the other directives or after the package clause
+	at, insert := lastDirective+1, []string{directive}
+	if lastDirective < 0 {
+		if packageLine < 0 {
+			return fmt.Errorf("no package clause in %s", filename)
+		}
+		at, insert = packageLine+1, []string{"", directive}
+	}
+
+	lines = append(lines[:at], append(insert, lines[at:]...)...)
+
+	return writeFileAtomically(fs, filename, []byte(strings.Join(lines, "\n")))
+}
+
+// directiveOutput returns the --output of the arguments.
+func directiveOutput(args []string) string {
+	for i, arg := range args {
+		for _, name := range []string{"--output", "-o"} {
+			if arg == name && i+1 < len(args) {
+				return args[i+1]
+			}
+			if strings.HasPrefix(arg, name+"=") {
+				return strings.TrimPrefix(arg, name+"=")
+			}
+		}
+	}
+
+	return ""
+}
+
+// dropFlag removes the flag, along with its value, from the arguments.
+func dropFlag(args []string, name string) []string {
+	result := make([]string, 0, len(args))
+
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == name:
+			i++
+		case strings.HasPrefix(args[i], name+"="):
+		default:
+			result = append(result, args[i])
+		}
+	}
+
+	return result
+}
+
+// pathFlags take the local paths, relative to the directory ifacemaker
+// runs in, while go generate runs it in the one of the directive file.
+var pathFlags = []string{"--output", "-o", "--source-dir", "--module-cache-dir", "--license-file", "--prelude-file"}
+
+// rebasePaths makes the relative paths of the arguments and the response
+// files relative to the directory of the directive instead of workDir.
+func rebasePaths(args []string, workDir, dir string) []string {
+	result := make([]string, 0, len(args))
+	rebase := func(value string) string {
+		if value == "" || filepath.IsAbs(value) {
+			return value
+		}
+		if rel, err := filepath.Rel(dir, filepath.Join(workDir, value)); err == nil {
+			return filepath.ToSlash(rel)
+		}
+		return value
+	}
+
+	isPathFlag := func(name string) bool {
+		for _, flag := range pathFlags {
+			if name == flag {
+				return true
+			}
+		}
+		return false
+	}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		name, value, hasValue := strings.Cut(arg, "=")
+
+		switch {
+		case strings.HasPrefix(arg, "@"):
+			arg = "@" + rebase(arg[1:])
+		case isPathFlag(name) && hasValue:
+			arg = name + "=" + rebase(value)
+		case isPathFlag(arg) && i+1 < len(args):
+			result = append(result, arg)
+			i++
+			arg = rebase(args[i])
+		}
+
+		result = append(result, arg)
+	}
+
+	return result
+}
+
+// directiveArgs returns the flags of the arguments the generator options
+// don't carry, the paths rebased from workDir to the directive directory.
+func directiveArgs(args arguments, workDir, dir string) []string {
+	var result []string
+
+	if args.SourceDir != "" {
+		result = append(result, "--source-dir", args.SourceDir)
+	}
+	// relative to the package directory, not rebased
+	if args.SourceFile != "" {
+		result = append(result, "--source-file", args.SourceFile)
+		if args.WithSiblings {
+			result = append(result, "--with-siblings")
+		}
+	}
+	if args.FileGlob != "" {
+		result = append(result, "--file-glob", args.FileGlob)
+	}
+	if args.LicenseFile != "" {
+		result = append(result, "--license-file", args.LicenseFile)
+	}
+	if args.ModuleCacheDir != "" {
+		result = append(result, "--module-cache-dir", args.ModuleCacheDir)
+	}
+	if args.BuildConstraintEval {
+		result = append(result, "--build-constraint-eval")
+	}
+	if args.PreludeFile != "" {
+		result = append(result, "--prelude-file", args.PreludeFile)
+	}
+
+	return rebasePaths(result, workDir, dir)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"path/filepath"
+	"regexp"
+	"runtime/debug"
+	"strings"
+
+	"github.com/denisdubovitskiy/ifacemaker/internal/generator"
+)
+
+// buildVersion returns a version of the
+// ifacemaker module the binary is built from.
+func buildVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok || info.Main.Version == "" {
+		return "(devel)"
+	}
+	return info.Main.Version
+}
+
+var (
+	secretFlagRe = regexp.MustCompile(`(?i)(token|secret|password|passwd|credential)`)
+	userinfoRe   = regexp.MustCompile(`://[^/@\s]+@`)
+)
+
+const redacted = "REDACTED"
+
+// invocationArgs prepares the command line arguments to be written to the
+// generated file. Secrets are redacted and absolute paths are made relative
+// to the working directory, so the output doesn't depend on the machine.
+func invocationArgs(args []string, workDir string) []string {
+	result := make([]string, 0, len(args))
+	redactNext := false
+
+	for _, arg := range args {
+		switch {
+		case redactNext && !strings.HasPrefix(arg, "-"):
+			arg = redacted
+		case strings.HasPrefix(arg, "-"):
+			name, value, hasValue := strings.Cut(arg, "=")
+			switch {
+			case secretFlagRe.MatchString(name) && hasValue:
+				arg = name + "=" + redacted
+			case secretFlagRe.MatchString(name):
+				redactNext = true
+				result = append(result, generator.QuoteArg(arg))
+				continue
+			case hasValue:
+				arg = name + "=" + portableValue(value, workDir)
+			}
+		default:
+			arg = portableValue(arg, workDir)
+		}
+
+		redactNext = false
+		result = append(result, generator.QuoteArg(arg))
+	}
+
+	return result
+}
+
+func portableValue(value, workDir string) string {
+	value = userinfoRe.ReplaceAllString(value, "://")
+
+	if !filepath.IsAbs(value) {
+		return value
+	}
+
+	if rel, err := filepath.Rel(workDir, value); err == nil {
+		return filepath.ToSlash(rel)
+	}
+
+	return filepath.Base(value)
+}
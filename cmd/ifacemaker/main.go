@@ -1,26 +1,269 @@
 package main
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go/build"
+	"go/token"
+	"io"
 	"log"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"text/tabwriter"
 
 	"github.com/denisdubovitskiy/ifacemaker/internal/generator"
 	"github.com/denisdubovitskiy/ifacemaker/internal/golang"
 	"github.com/denisdubovitskiy/ifacemaker/internal/gomodule"
 	"github.com/jessevdk/go-flags"
 	"github.com/spf13/afero"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/semver"
 )
 
 type arguments struct {
-	SourcePackage  string `short:"s" long:"source-pkg" description:"Go import path to struct" required:"true"`
-	SourceVersion  string `short:"v" long:"source-version" description:"Semantic version of the source package (example: v1.9.0)" required:"false"`
-	ModulePath     string `short:"m" long:"module-path" description:"Submodule path from the root" required:"false"`
-	ResultPackage  string `short:"p" long:"result-pkg" description:"Result package name" required:"true"`
-	StructName     string `short:"t" long:"struct-name" description:"A structure name to generate interface for" required:"true"`
-	InterfaceName  string `short:"i" long:"interface-name" description:"Name of the generated interface" required:"true"`
-	OutputFileName string `short:"o" long:"output" description:"OutputFileName file name"`
+	SourcePackage          string   `short:"s" long:"source-pkg" env:"IFACEMAKER_SOURCE_PKG" description:"Go import path to struct" required:"true"`
+	SourceVersion          string   `short:"v" long:"source-version" env:"IFACEMAKER_SOURCE_VERSION" description:"Semantic version of the source package (example: v1.9.0)" required:"false"`
+	SourceDir              string   `long:"source-dir" env:"IFACEMAKER_SOURCE_DIR" description:"A directory the source module is extracted to or its .zip archive, read instead of the module cache"`
+	ModuleCacheDir         string   `long:"module-cache-dir" env:"IFACEMAKER_MODULE_CACHE_DIR" description:"A module cache directory the modules are read from instead of GOMODCACHE"`
+	BuildConstraintEval    bool     `long:"build-constraint-eval" description:"Skip the files excluded by the build constraints for the current GOOS and GOARCH"`
+	ModulePath             string   `short:"m" long:"module-path" env:"IFACEMAKER_MODULE_PATH" description:"Submodule path from the root" required:"false"`
+	SourceImportPath       string   `long:"source-import-path" description:"Import path of the source package in the result when it differs from the fetched one, a vanity path (example: go.uber.org/zap)"`
+	ResultPackage          string   `short:"p" long:"result-pkg" env:"IFACEMAKER_RESULT_PKG" description:"Result package name"`
+	StructName             string   `short:"t" long:"struct-name" description:"A structure name to generate interface for"`
+	InterfaceName          string   `short:"i" long:"interface-name" description:"Name of the generated interface"`
+	OutputFileName         string   `short:"o" long:"output" description:"OutputFileName file name, or a directory the file name is derived from the interface name in"`
+	FilenameCase           string   `long:"filename-case" description:"Case of the file name derived from the interface name" choice:"snake" choice:"kebab" choice:"lower" default:"snake"`
+	CopyTypeDoc            bool     `long:"copy-type-doc" description:"Copy the struct doc comment to the generated interface"`
+	ReplaceUnexportedWith  string   `long:"replace-unexported-with" description:"Replace the unexported types of the source package with any, interface{} or a type of the output package"`
+	UseAny                 bool     `long:"use-any" description:"Spell the empty interfaces as any"`
+	GoVersion              string   `long:"go-version" env:"IFACEMAKER_GO_VERSION" description:"Go version the result is compiled with (example: 1.17)"`
+	CommentWidth           int      `long:"comment-width" description:"Rewrap the prose of the copied docs at the column (example: 100)"`
+	IndentSpaces           int      `long:"indent-spaces" description:"Indent the result file with the number of spaces instead of tabs"`
+	GroupImports           bool     `long:"group-imports" description:"Group the imports of the result file by standard and third-party packages"`
+	NoImports              bool     `long:"no-imports" description:"Leave the import block out for a snippet which imports are handled elsewhere, the result may not compile"`
+	LicenseFile            string   `long:"license-file" description:"A file which contents are prepended to the result as a license header"`
+	PreludeFile            string   `long:"prelude-file" description:"A file of Go declarations inserted after the imports, before the interfaces"`
+	HeaderVersion          bool     `long:"header-version" description:"Write the ifacemaker version and the invocation arguments to the header"`
+	SkipUnchanged          bool     `long:"skip-unchanged" description:"Write a hash of the sources to the header and skip the generation if the output has the same one"`
+	Force                  bool     `long:"force" description:"Generate the output even if --skip-unchanged finds it up to date"`
+	Check                  bool     `long:"check" description:"Compare the output with the one generated instead of writing it, exit with 2 if it is outdated and with 3 if the interfaces changed incompatibly"`
+	WriteDirective         string   `long:"write-directive" description:"A Go file of the package to write the go:generate directive repeating the invocation to, the one with the same --output is updated"`
+	SourceFile             string   `short:"f" long:"source-file" description:"A single file of the source package to collect the methods from"`
+	WithSiblings           bool     `long:"with-siblings" description:"Resolve the types declared in the other files of the package when --source-file is set"`
+	FileGlob               string   `long:"file-glob" description:"Parse only the source package files which names match a glob, client_*.go"`
+	AnnotateSource         bool     `long:"annotate-source" description:"Write the import path of the source struct to the interface doc"`
+	TrimPrefix             string   `long:"trim-prefix" description:"An import path prefix trimmed in the source annotation"`
+	PreserveOrder          bool     `long:"preserve-order" description:"Order the methods by the source filename and position"`
+	Role                   []string `long:"role" description:"Generate an interface with the methods matching a regexp, Name=regexp (repeatable)"`
+	FuncType               string   `long:"func-type" description:"Generate a function type of the struct method signature named --interface-name instead of the interface"`
+	EmitStub               bool     `long:"emit-stub" description:"Add a struct implementing the interface, which methods return the zero values"`
+	CheckErrorLast         bool     `long:"check-error-last" description:"Warn about the methods not returning the error as the last result"`
+	WidenVariadic          bool     `long:"widen-variadic" description:"Render the variadic parameters as slices, changing the way the methods are called"`
+	BestEffort             bool     `long:"best-effort" description:"Replace the unsupported type expressions with any instead of failing"`
+	FlagMissingContext     bool     `long:"flag-missing-context" description:"Mark the methods not taking a context.Context first as deprecated"`
+	AddedSince             string   `long:"added-since" description:"Generate only the methods added or changed since an older version of the source package (example: v1.8.0)"`
+	Include                string   `long:"include" description:"Keep only the struct methods matching a regexp"`
+	Exclude                string   `long:"exclude" description:"Drop the struct methods matching a regexp"`
+	FilterPromoted         bool     `long:"filter-promoted" description:"Apply --include and --exclude to the methods promoted from the embedded types too"`
+	MethodSet              string   `long:"method-set" description:"Method set of the struct the interface lists, the value one leaves out the pointer receiver methods" choice:"pointer" choice:"value" default:"pointer"`
+	IncludeUnexported      bool     `long:"include-unexported" description:"Include the unexported methods, the output has to be in the source package"`
+	AllStructs             bool     `long:"all-structs" description:"Generate an interface for every exported struct of the package into the --output directory"`
+	FactorCommon           string   `long:"factor-common" description:"Move the methods every --all-structs interface has to an interface of the name they embed"`
+	Embed                  []string `long:"embed" description:"Embed an interface in the generated one, importpath.Name (repeatable)"`
+	EmbedNoDedup           bool     `long:"embed-no-dedup" description:"List the struct methods the embedded interfaces have as well"`
+	GroupByPrefix          []string `long:"group-by-prefix" description:"List the methods with a name prefix under a banner comment, Banner=Prefix (repeatable)"`
+	ReuseExistingInterface bool     `long:"reuse-existing-interface" description:"Embed the interface of the source package the struct implements instead of listing its methods"`
+	Rename                 []string `long:"rename" description:"Rename a method in the interface, old=new (repeatable)"`
+	StripMethodPrefix      string   `long:"strip-method-prefix" description:"Remove a prefix from the method names in the interface (example: API for APIGetUser)"`
+	Order                  string   `long:"order" description:"List these methods first in the given order and the rest sorted by name, comma-separated (example: Get,Set)"`
+	List                   bool     `short:"l" long:"list" description:"List the exported structs of the source package and exit"`
+}
+
+// codeHosts are the hosts which module paths are host/owner/repo, so the
+// path of a package there tells the module and the directory apart.
+var codeHosts = map[string]struct{}{
+	"github.com":    {},
+	"gitlab.com":    {},
+	"bitbucket.org": {},
+}
+
+var majorVersionRe = regexp.MustCompile(`^v\d+$`)
+
+// sourceRef is a --source-pkg split into its parts, each of them may be
+// empty: github.com/hashicorp/vault@v1.8.2/api.Client is the module
+// github.com/hashicorp/vault of version v1.8.2, api is the directory of the
+// package in the module and Client is the struct.
+type sourceRef struct {
+	Module  string
+	Version string
+	Subdir  string
+	Struct  string
+}
+
+// parseSourceRef splits the --source-pkg into its parts. Without a version
+// the module is only told from the package for the codeHosts, see
+// splitModulePath, the whole path is the module path otherwise.
+func parseSourceRef(value string) sourceRef {
+	var ref sourceRef
+
+	// gopkg.in/yaml.v2 ends with a version, not a struct
+	if i := strings.LastIndex(value, "."); i > strings.LastIndex(value, "/") {
+		if name := value[i+1:]; token.IsIdentifier(name) && token.IsExported(name) {
+			ref.Struct = name
+			value = value[:i]
+		}
+	}
+
+	if modulePath, rest, ok := strings.Cut(value, "@"); ok {
+		ref.Module = modulePath
+		ref.Version, ref.Subdir, _ = strings.Cut(rest, "/")
+		return ref
+	}
+
+	ref.Module = value
+	if ref.Struct != "" {
+		ref.Module, ref.Subdir = splitModulePath(value)
+	}
+
+	return ref
+}
+
+// expandSourcePackage moves the parts of the --source-pkg to the flags they
+// stand for: github.com/hashicorp/vault@v1.8.2/api.Client is --source-pkg
+// github.com/hashicorp/vault@v1.8.2 --module-path api --struct-name Client.
+// The flags set explicitly override the parts.
+func (a *arguments) expandSourcePackage() {
+	ref := parseSourceRef(a.SourcePackage)
+
+	a.SourcePackage = ref.Module
+	// gomodule.Parse takes the version from the path unless it's set
+	if ref.Version != "" && a.SourceVersion == "" {
+		a.SourcePackage += "@" + ref.Version
+	}
+	if a.ModulePath == "" {
+		a.ModulePath = ref.Subdir
+	}
+	if a.StructName == "" {
+		a.StructName = ref.Struct
+	}
+}
+
+// splitModulePath splits the path of a package into the module path and the
+// directory of the package: github.com/hashicorp/vault/api into
+// github.com/hashicorp/vault and api. The paths of the other hosts are
+// taken as the module ones.
+func splitModulePath(pkg string) (string, string) {
+	elems := strings.Split(pkg, "/")
+	if _, ok := codeHosts[elems[0]]; !ok || len(elems) <= 3 {
+		return pkg, ""
+	}
+
+	n := 3
+	if majorVersionRe.MatchString(elems[n]) {
+		n++
+	}
+
+	return strings.Join(elems[:n], "/"), strings.Join(elems[n:], "/")
+}
+
+// validate checks the flags required for the generation,
+// they can't be marked as required since --list doesn't need them.
+func (a arguments) validate() error {
+	var missing []string
+
+	if a.ResultPackage == "" {
+		missing = append(missing, "`-p, --result-pkg'")
+	}
+	if a.StructName == "" && !a.AllStructs {
+		missing = append(missing, "`-t, --struct-name'")
+	}
+	if a.InterfaceName == "" && len(a.Role) == 0 && !a.AllStructs {
+		missing = append(missing, "`-i, --interface-name'")
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("the required flags %s were not specified", strings.Join(missing, ", "))
+	}
+
+	// foo_test is an identifier as well
+	if !token.IsIdentifier(a.ResultPackage) {
+		return fmt.Errorf("invalid result package name %q", a.ResultPackage)
+	}
+
+	if strings.HasSuffix(a.ResultPackage, "_test") && strings.HasSuffix(a.OutputFileName, ".go") && !strings.HasSuffix(a.OutputFileName, "_test.go") {
+		return fmt.Errorf("the output file of package %s has to end with _test.go", a.ResultPackage)
+	}
+
+	// the structs to generate are only known after parsing
+	if a.AllStructs && a.SkipUnchanged {
+		return errors.New("--skip-unchanged can't be used with --all-structs")
+	}
+
+	// the role interfaces of the structs would clash
+	if a.AllStructs && len(a.Role) > 0 {
+		return errors.New("--role can't be used with --all-structs")
+	}
+
+	if a.FactorCommon != "" && !a.AllStructs {
+		return errors.New("--factor-common can only be used with --all-structs")
+	}
+
+	if a.FactorCommon != "" && !(token.IsIdentifier(a.FactorCommon) && token.IsExported(a.FactorCommon)) {
+		return fmt.Errorf("invalid --factor-common interface name %q", a.FactorCommon)
+	}
+
+	// a single type is generated for a single method
+	if a.FuncType != "" && (a.AllStructs || len(a.Role) > 0) {
+		return errors.New("--func-type can't be used with --all-structs or --role")
+	}
+
+	if generator.IsOutputTemplate(a.OutputFileName) {
+		if _, err := generator.ParseOutputTemplate(a.OutputFileName); err != nil {
+			return fmt.Errorf("invalid --output template: %v", err)
+		}
+	}
+
+	if a.WriteDirective != "" && !strings.HasSuffix(a.WriteDirective, ".go") {
+		return fmt.Errorf("the --write-directive file %s has to be a Go file", a.WriteDirective)
+	}
+
+	if _, err := filepath.Match(a.FileGlob, ""); err != nil {
+		return fmt.Errorf("invalid --file-glob %q: %v", a.FileGlob, err)
+	}
+
+	if a.AddedSince != "" && !semver.IsValid(a.AddedSince) {
+		return fmt.Errorf("invalid --added-since version %q", a.AddedSince)
+	}
+
+	// any is an identifier as well
+	if a.ReplaceUnexportedWith != "" && a.ReplaceUnexportedWith != "interface{}" && !token.IsIdentifier(a.ReplaceUnexportedWith) {
+		return fmt.Errorf("invalid --replace-unexported-with type %q", a.ReplaceUnexportedWith)
+	}
+
+	if a.GoVersion != "" && !generator.ValidGoVersion(a.GoVersion) {
+		return fmt.Errorf("invalid go version %q", a.GoVersion)
+	}
+
+	// the last version would be looked up in the module cache
+	if a.SourceDir != "" && a.SourceVersion == "" && !strings.Contains(a.SourcePackage, "@") {
+		return errors.New("--source-dir needs the version of the source package, --source-version or --source-pkg module@version")
+	}
+
+	return nil
+}
+
+// parseArguments parses the command line, the flags
+// not set there default to the environment variables.
+func parseArguments(osArgs []string) (arguments, error) {
+	var args arguments
+	_, err := flags.ParseArgs(&args, osArgs)
+	return args, err
 }
 
 // ifacemaker \
@@ -33,49 +276,540 @@ type arguments struct {
 // --output mattermost/client.go
 
 func main() {
-	var args arguments
+	exitCode, err := run()
+	if err != nil {
+		log.Fatal(err)
+	}
+	os.Exit(exitCode)
+}
+
+// run generates the interfaces and returns the exit code of --check, the
+// errors are returned rather than fatal, so the deferred cleanups run.
+func run() (int, error) {
+	osArgs, err := expandResponseFiles(osFs, os.Args)
+	if err != nil {
+		return 0, err
+	}
 
-	if _, err := flags.ParseArgs(&args, os.Args); err != nil {
+	args, err := parseArguments(osArgs)
+	if err != nil {
 		if flags.WroteHelp(err) {
-			return
+			return 0, nil
 		}
 
-		os.Exit(1)
+		// the parser has printed the error
+		return 1, nil
 	}
 
-	// gets passed when executed as `go generate`
-	if gofile := golang.GOFILE(); len(gofile) > 0 {
+	args.expandSourcePackage()
+
+	if !args.List {
+		if err := args.validate(); err != nil {
+			return 0, err
+		}
+	}
+
+	// gets passed when executed as `go generate`, an explicit --output
+	// wins, the directive may be in the source file, and the output
+	// of --all-structs is a directory
+	if gofile := golang.GOFILE(); len(gofile) > 0 && args.OutputFileName == "" && !args.AllStructs {
 		args.OutputFileName = gofile
 	}
 
+	if !args.AllStructs {
+		interfaceName := args.InterfaceName
+		if interfaceName == "" {
+			// the role interfaces share the file
+			interfaceName = args.StructName
+		}
+		if generator.IsOutputTemplate(args.OutputFileName) {
+			output, err := executeOutputTemplate(args.OutputFileName, generator.OutputVars{
+				Struct:    args.StructName,
+				Interface: interfaceName,
+				Package:   args.ResultPackage,
+			})
+			if err != nil {
+				return 0, err
+			}
+			args.OutputFileName = output
+		}
+		args.OutputFileName = outputFile(osFs, args.OutputFileName, interfaceName, args.FilenameCase, args.ResultPackage)
+	}
+
+	if args.ModuleCacheDir != "" {
+		gomodule.UseModCache(args.ModuleCacheDir)
+	}
+
+	if args.BuildConstraintEval {
+		finder.evalBuildConstraints(build.Default)
+	}
+
 	module, err := gomodule.Parse(args.SourcePackage, args.SourceVersion)
 	if err != nil {
-		log.Fatal(err)
+		return 0, err
 	}
 
-	files, err := findSourceFiles(module.Directory(args.ModulePath))
+	if args.SourceDir != "" {
+		cleanup, err := gomodule.Open(module, args.SourceDir)
+		if err != nil {
+			return 0, err
+		}
+		defer cleanup()
+	}
+
+	sourceGoVersion, err := gomodule.GoVersion(module)
 	if err != nil {
-		log.Fatal(err)
+		return 0, err
 	}
 
-	generatedCode, err := generator.Generate(generator.Options{
-		Files:             files,
-		StructName:        args.StructName,
-		OutputPackageName: args.ResultPackage,
-		InterfaceName:     args.InterfaceName,
-		ModulePath:        args.ModulePath,
-		SourcePackage:     args.SourcePackage,
-		OutputFilename:    args.OutputFileName,
-	})
+	packageDir := module.Directory(args.ModulePath)
+
+	files, err := finder.findSourceFiles(packageDir)
 	if err != nil {
-		log.Fatal(err.Error())
+		return 0, err
+	}
+
+	if args.FileGlob != "" {
+		files = matchFiles(files, args.FileGlob)
+		if len(files) == 0 && args.SourceFile == "" {
+			return 0, fmt.Errorf("no source files of %s match %s", packageDir, args.FileGlob)
+		}
+	}
+
+	var siblings []string
+
+	if args.SourceFile != "" {
+		sourceFile, err := finder.findSourceFile(packageDir, args.SourceFile)
+		if err != nil {
+			return 0, err
+		}
+
+		if args.WithSiblings {
+			siblings = excludeFile(files, sourceFile)
+		}
+		files = []string{sourceFile}
+	}
+
+	if args.List {
+		types, err := generator.ListTypes(files)
+		if err != nil {
+			return 0, err
+		}
+		if err := printStructs(os.Stdout, types); err != nil {
+			return 0, err
+		}
+		return 0, nil
 	}
-	if err := os.MkdirAll(filepath.Dir(args.OutputFileName), os.ModePerm); err != nil {
-		log.Fatal(err.Error())
+
+	var baselineFiles []string
+
+	if args.AddedSince != "" {
+		modulePath, _, _ := strings.Cut(args.SourcePackage, "@")
+
+		baseline, err := gomodule.Parse(modulePath, args.AddedSince)
+		if err != nil {
+			return 0, err
+		}
+
+		baselineFiles, err = finder.findSourceFiles(baseline.Directory(args.ModulePath))
+		if err != nil {
+			return 0, err
+		}
+
+		if args.FileGlob != "" {
+			baselineFiles = matchFiles(baselineFiles, args.FileGlob)
+		}
 	}
-	if err := os.WriteFile(args.OutputFileName, generatedCode, 0644); err != nil {
-		log.Fatal(err.Error())
+
+	renames, err := parseRenames(args.Rename)
+	if err != nil {
+		return 0, err
+	}
+
+	order, err := parseOrder(args.Order)
+	if err != nil {
+		return 0, err
+	}
+
+	roles, err := parseRoles(args.Role)
+	if err != nil {
+		return 0, err
+	}
+
+	embeds, err := parseEmbeds(args.Embed)
+	if err != nil {
+		return 0, err
+	}
+
+	groups, err := parseGroups(args.GroupByPrefix)
+	if err != nil {
+		return 0, err
+	}
+
+	include, err := parseFilter("include", args.Include)
+	if err != nil {
+		return 0, err
+	}
+
+	exclude, err := parseFilter("exclude", args.Exclude)
+	if err != nil {
+		return 0, err
+	}
+
+	outputDir := filepath.Dir(args.OutputFileName)
+	if args.AllStructs {
+		outputDir = args.OutputFileName
+	}
+
+	outputPackage, err := outputImportPath(osFs, outputDir)
+	if err != nil {
+		return 0, err
 	}
+
+	workDir, err := os.Getwd()
+	if err != nil {
+		return 0, err
+	}
+
+	directiveDir, err := filepath.Abs(outputDir)
+	if err != nil {
+		return 0, err
+	}
+
+	// the directive repeats the version given apart
+	sourcePackage := args.SourcePackage
+	if args.SourceVersion != "" {
+		sourcePackage += "@" + args.SourceVersion
+	}
+
+	options := generator.Options{
+		Files:                  files,
+		StructName:             args.StructName,
+		OutputPackageName:      args.ResultPackage,
+		InterfaceName:          args.InterfaceName,
+		ModulePath:             args.ModulePath,
+		SourceImportPath:       args.SourceImportPath,
+		SourcePackage:          sourcePackage,
+		OutputFilename:         args.OutputFileName,
+		CopyTypeDoc:            args.CopyTypeDoc,
+		GroupImports:           args.GroupImports,
+		NoImports:              args.NoImports,
+		SiblingFiles:           siblings,
+		ReplaceUnexportedWith:  args.ReplaceUnexportedWith,
+		UseAny:                 args.UseAny,
+		GoVersion:              args.GoVersion,
+		SourceGoVersion:        sourceGoVersion,
+		Rename:                 renames,
+		StripMethodPrefix:      args.StripMethodPrefix,
+		Order:                  order,
+		Roles:                  roles,
+		FuncType:               args.FuncType,
+		EmitStub:               args.EmitStub,
+		Embeds:                 embeds,
+		EmbedNoDedup:           args.EmbedNoDedup,
+		Groups:                 groups,
+		ReuseExistingInterface: args.ReuseExistingInterface,
+		FlagMissingContext:     args.FlagMissingContext,
+		BestEffort:             args.BestEffort,
+		CheckErrorLast:         args.CheckErrorLast,
+		WidenVariadic:          args.WidenVariadic,
+		AddedSince:             args.AddedSince,
+		BaselineFiles:          baselineFiles,
+		FilenameCase:           args.FilenameCase,
+		FactorCommon:           args.FactorCommon,
+		Include:                include,
+		Exclude:                exclude,
+		FilterPromoted:         args.FilterPromoted,
+		MethodSet:              args.MethodSet,
+		IncludeUnexported:      args.IncludeUnexported,
+		OutputImportPath:       outputPackage,
+		PreserveOrder:          args.PreserveOrder,
+		AnnotateSource:         args.AnnotateSource,
+		TrimPrefix:             args.TrimPrefix,
+		CommentWidth:           args.CommentWidth,
+		IndentSpaces:           args.IndentSpaces,
+		DirectiveArgs:          directiveArgs(args, workDir, directiveDir),
+		ResolvePackage:         newPackageResolver(module),
+		Warn: func(message string) {
+			log.Println("warning:", message)
+		},
+	}
+
+	if args.LicenseFile != "" {
+		license, err := os.ReadFile(args.LicenseFile)
+		if err != nil {
+			return 0, err
+		}
+		options.License = string(license)
+	}
+
+	if args.PreludeFile != "" {
+		prelude, err := os.ReadFile(args.PreludeFile)
+		if err != nil {
+			return 0, err
+		}
+		options.Prelude = string(prelude)
+	}
+
+	if args.HeaderVersion {
+		options.Version = buildVersion()
+		options.Invocation = invocationArgs(os.Args[1:], workDir)
+	}
+
+	if args.SkipUnchanged {
+		hashed := append(append(append([]string{}, files...), siblings...), baselineFiles...)
+		if args.LicenseFile != "" {
+			hashed = append(hashed, args.LicenseFile)
+		}
+		if args.PreludeFile != "" {
+			hashed = append(hashed, args.PreludeFile)
+		}
+
+		hash, err := hashSources(osFs, hashed, append([]string{buildVersion()}, hashedArgs(osArgs[1:])...))
+		if err != nil {
+			return 0, err
+		}
+		options.SourceHash = hash
+
+		if !args.Force && upToDate(osFs, args.OutputFileName, hash) {
+			log.Printf("%s is up to date, skipping it", args.OutputFileName)
+			return 0, nil
+		}
+	}
+
+	if args.AllStructs {
+		if generator.IsOutputTemplate(args.OutputFileName) {
+			if options.OutputTemplate, err = generator.ParseOutputTemplate(args.OutputFileName); err != nil {
+				return 0, err
+			}
+		}
+
+		files, err := generator.GenerateAll(options)
+		if err != nil {
+			return 0, err
+		}
+		// the worst of the files with --check
+		var exitCode int
+		for _, f := range files {
+			if args.Check {
+				code, err := checkFile(osFs, f.Filename, f.Code)
+				if err != nil {
+					return 0, err
+				}
+				if code > exitCode {
+					exitCode = code
+				}
+				continue
+			}
+			if err := writeFileAtomically(osFs, f.Filename, f.Code); err != nil {
+				return 0, err
+			}
+		}
+		return exitCode, addDirective(args)
+	}
+
+	generatedCode, err := generator.Generate(options)
+	if err != nil {
+		return 0, err
+	}
+	if args.Check {
+		return checkFile(osFs, args.OutputFileName, generatedCode)
+	}
+	if err := writeFileAtomically(osFs, args.OutputFileName, generatedCode); err != nil {
+		return 0, err
+	}
+	return 0, addDirective(args)
+}
+
+// The exit codes of --check, the breaking changes outweigh the others.
+const (
+	exitOutdated = 2
+	exitBreaking = 3
+)
+
+// addDirective writes the go:generate directive repeating the
+// invocation to the --write-directive file if one is set.
+func addDirective(args arguments) error {
+	if args.WriteDirective == "" {
+		return nil
+	}
+
+	workDir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	filename, err := filepath.Abs(args.WriteDirective)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(filename)
+	invocation := invocationArgs(rebasePaths(dropFlag(os.Args[1:], "--write-directive"), workDir, dir), dir)
+	return writeDirective(osFs, filename, args.ResultPackage, invocation)
+}
+
+// hashedArgs drops --force, --check and --write-directive from the arguments,
+// so neither a forced run, a check nor the directive changes the hash of the
+// sources.
+func hashedArgs(args []string) []string {
+	hashed := make([]string, 0, len(args))
+	for _, arg := range dropFlag(args, "--write-directive") {
+		if arg != "--force" && arg != "--check" {
+			hashed = append(hashed, arg)
+		}
+	}
+	return hashed
+}
+
+// newPackageResolver looks up packages in the vendor directories and then in
+// the module cache using the versions required by the source module.
+func newPackageResolver(source *gomodule.Module) func(importPath string) ([]string, error) {
+	vendor := finder.vendorDirs(source.Directory(""))
+
+	return func(importPath string) ([]string, error) {
+		if dir, ok := finder.vendoredPackage(vendor, importPath); ok {
+			return finder.findSourceFiles(dir)
+		}
+
+		module, subdir, err := gomodule.Resolve(source, importPath)
+		if err != nil {
+			return nil, err
+		}
+
+		return finder.findSourceFiles(module.Directory(subdir))
+	}
+}
+
+// parseRenames parses the old=new pairs of --rename.
+func parseRenames(values []string) (map[string]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	renames := make(map[string]string, len(values))
+
+	for _, v := range values {
+		oldName, newName, ok := strings.Cut(v, "=")
+		if !ok || oldName == "" || newName == "" {
+			return nil, fmt.Errorf("invalid rename %q, expected old=new", v)
+		}
+		if _, ok := renames[oldName]; ok {
+			return nil, fmt.Errorf("method %s is renamed more than once", oldName)
+		}
+
+		renames[oldName] = newName
+	}
+
+	return renames, nil
+}
+
+// parseOrder parses the comma-separated method names of --order.
+func parseOrder(value string) ([]string, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	names := strings.Split(value, ",")
+	seen := make(map[string]struct{}, len(names))
+
+	for i, name := range names {
+		name = strings.TrimSpace(name)
+		if !token.IsIdentifier(name) {
+			return nil, fmt.Errorf("invalid method name %q in --order", name)
+		}
+		if _, ok := seen[name]; ok {
+			return nil, fmt.Errorf("method %s is ordered more than once", name)
+		}
+
+		seen[name] = struct{}{}
+		names[i] = name
+	}
+
+	return names, nil
+}
+
+// parseRoles parses the Name=regexp pairs of --role.
+func parseRoles(values []string) ([]generator.Role, error) {
+	roles := make([]generator.Role, 0, len(values))
+
+	for _, v := range values {
+		name, filter, ok := strings.Cut(v, "=")
+		if !ok || name == "" || filter == "" {
+			return nil, fmt.Errorf("invalid role %q, expected Name=regexp", v)
+		}
+
+		re, err := regexp.Compile(filter)
+		if err != nil {
+			return nil, fmt.Errorf("invalid role %s filter: %v", name, err)
+		}
+
+		roles = append(roles, generator.Role{Name: name, Filter: re})
+	}
+
+	return roles, nil
+}
+
+// parseGroups parses the Banner=Prefix pairs of --group-by-prefix.
+func parseGroups(values []string) ([]generator.Group, error) {
+	groups := make([]generator.Group, 0, len(values))
+
+	for _, v := range values {
+		banner, prefix, ok := strings.Cut(v, "=")
+		if !ok || banner == "" || prefix == "" {
+			return nil, fmt.Errorf("invalid group %q, expected Banner=Prefix", v)
+		}
+
+		groups = append(groups, generator.Group{Banner: banner, Prefix: prefix})
+	}
+
+	return groups, nil
+}
+
+// parseEmbeds parses the importpath.Name values of --embed,
+// the name is the part after the last dot.
+func parseEmbeds(values []string) ([]generator.Embed, error) {
+	embeds := make([]generator.Embed, 0, len(values))
+
+	for _, v := range values {
+		i := strings.LastIndex(v, ".")
+		if i <= 0 || i == len(v)-1 || strings.Contains(v[i+1:], "/") {
+			return nil, fmt.Errorf("invalid embed %q, expected importpath.Name", v)
+		}
+
+		embeds = append(embeds, generator.Embed{ImportPath: v[:i], Name: v[i+1:]})
+	}
+
+	return embeds, nil
+}
+
+// parseFilter compiles the regexp of --include or --exclude.
+func parseFilter(flag, value string) (*regexp.Regexp, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	re, err := regexp.Compile(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --%s filter: %v", flag, err)
+	}
+
+	return re, nil
+}
+
+func printStructs(w io.Writer, types []generator.TypeInfo) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	for _, t := range types {
+		if t.Kind != generator.TypeKindStruct {
+			continue
+		}
+		if _, err := fmt.Fprintf(tw, "%s\t%d\n", t.Name, t.Methods); err != nil {
+			return err
+		}
+	}
+
+	return tw.Flush()
 }
 
 func newSourceFilesFinder() *sourceFilesFinder {
@@ -84,6 +818,18 @@ func newSourceFilesFinder() *sourceFilesFinder {
 
 type sourceFilesFinder struct {
 	fs afero.Fs
+
+	// the files are matched against when set, see evalBuildConstraints
+	buildContext *build.Context
+}
+
+// evalBuildConstraints makes findSourceFiles skip the files excluded by the
+// build constraints in the context, the _GOOS and _GOARCH suffixes included.
+func (f *sourceFilesFinder) evalBuildConstraints(ctx build.Context) {
+	ctx.OpenFile = func(path string) (io.ReadCloser, error) {
+		return f.fs.Open(path)
+	}
+	f.buildContext = &ctx
 }
 
 func (f *sourceFilesFinder) findSourceFiles(directory string) ([]string, error) {
@@ -104,10 +850,284 @@ func (f *sourceFilesFinder) findSourceFiles(directory string) ([]string, error)
 			continue
 		}
 
+		if f.buildContext != nil {
+			match, err := f.buildContext.MatchFile(directory, e.Name())
+			if err != nil {
+				return nil, err
+			}
+			if !match {
+				continue
+			}
+		}
+
 		files = append(files, filepath.Join(directory, e.Name()))
 	}
 
 	return files, nil
 }
 
-var findSourceFiles = newSourceFilesFinder().findSourceFiles
+// findSourceFile validates a single source file, a relative
+// name is looked up in the package directory.
+func (f *sourceFilesFinder) findSourceFile(directory, name string) (string, error) {
+	filename := name
+	if !filepath.IsAbs(filename) {
+		filename = filepath.Join(directory, name)
+	}
+
+	info, err := f.fs.Stat(filename)
+	if err != nil {
+		return "", err
+	}
+
+	if info.IsDir() || !strings.HasSuffix(filename, ".go") {
+		return "", fmt.Errorf("%s is not a go source file", filename)
+	}
+
+	return filename, nil
+}
+
+// vendorDirs returns the vendor directories the dependencies of the module
+// are looked up in first: the one of the module itself and the one the module
+// is vendored to, /src/app/vendor for /src/app/vendor/github.com/acme/lib.
+func (f *sourceFilesFinder) vendorDirs(moduleDir string) []string {
+	var dirs []string
+
+	if isDir, _ := afero.IsDir(f.fs, filepath.Join(moduleDir, "vendor")); isDir { //nolint:errcheck // no vendor directory
+		dirs = append(dirs, filepath.Join(moduleDir, "vendor"))
+	}
+
+	for dir := filepath.Dir(moduleDir); dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+		if filepath.Base(dir) == "vendor" {
+			dirs = append(dirs, dir)
+			break
+		}
+	}
+
+	return dirs
+}
+
+// vendoredPackage returns a directory of the package vendored to one of the directories.
+func (f *sourceFilesFinder) vendoredPackage(vendorDirs []string, importPath string) (string, bool) {
+	for _, vendor := range vendorDirs {
+		dir := filepath.Join(vendor, filepath.FromSlash(importPath))
+		if isDir, _ := afero.IsDir(f.fs, dir); isDir { //nolint:errcheck // not vendored
+			return dir, true
+		}
+	}
+
+	return "", false
+}
+
+// matchFiles keeps the files which base names match the glob.
+func matchFiles(files []string, glob string) []string {
+	var matched []string
+	for _, f := range files {
+		if ok, _ := filepath.Match(glob, filepath.Base(f)); ok { //nolint:errcheck // validated with the arguments
+			matched = append(matched, f)
+		}
+	}
+	return matched
+}
+
+func excludeFile(files []string, filename string) []string {
+	result := make([]string, 0, len(files))
+	for _, f := range files {
+		if filepath.Clean(f) != filepath.Clean(filename) {
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+// outputFile returns the file the result is written to. When the output is
+// a directory, told by a trailing slash or by the existing one, the file name
+// is derived from the interface name, a _test.go one for a _test package.
+func outputFile(fs afero.Fs, output, interfaceName, nameCase, packageName string) string {
+	isDir := strings.HasSuffix(output, "/") || strings.HasSuffix(output, string(filepath.Separator))
+	if !isDir {
+		isDir, _ = afero.IsDir(fs, output) //nolint:errcheck // a file to be created
+	}
+
+	if isDir {
+		filename := generator.PackageFilename(generator.Filename(interfaceName, nameCase), packageName)
+		return filepath.Join(output, filename)
+	}
+
+	return output
+}
+
+// executeOutputTemplate makes the output path of the --output template.
+func executeOutputTemplate(output string, vars generator.OutputVars) (string, error) {
+	tmpl, err := generator.ParseOutputTemplate(output)
+	if err != nil {
+		return "", err
+	}
+
+	filename, err := generator.OutputPath(tmpl, vars)
+	if err != nil {
+		return "", fmt.Errorf("making the output path: %v", err)
+	}
+
+	return filename, nil
+}
+
+// hashSources returns a hash of the files contents and of the arguments
+// which change the result for the same files. The files are told by their
+// base names, so the hash doesn't depend on where the module cache is.
+func hashSources(fs afero.Fs, files, args []string) (string, error) {
+	h := sha256.New()
+
+	for _, arg := range args {
+		fmt.Fprintf(h, "%d:%s\n", len(arg), arg)
+	}
+
+	for _, file := range files {
+		content, err := afero.ReadFile(fs, file)
+		if err != nil {
+			return "", err
+		}
+
+		name := filepath.Base(file)
+		fmt.Fprintf(h, "%d:%s\n%d:", len(name), name, len(content))
+		h.Write(content)
+	}
+
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// upToDate reports whether the output file is generated
+// from the sources of the hash, see hashSources.
+func upToDate(fs afero.Fs, outputFile, hash string) bool {
+	code, err := afero.ReadFile(fs, outputFile)
+	if err != nil {
+		return false
+	}
+	return generator.ReadSourceHash(code) == hash
+}
+
+// checkFile compares the code with the contents of the file and logs the
+// changes of the interfaces, the exit code is zero if they are the same.
+func checkFile(fs afero.Fs, filename string, code []byte) (int, error) {
+	existing, err := afero.ReadFile(fs, filename)
+	if errors.Is(err, os.ErrNotExist) {
+		log.Printf("%s doesn't exist", filename)
+		return exitOutdated, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if bytes.Equal(existing, code) {
+		return 0, nil
+	}
+
+	changes, err := generator.CompareInterfaces(existing, code)
+	if err != nil {
+		return 0, fmt.Errorf("comparing with %s: %v", filename, err)
+	}
+
+	if len(changes) == 0 {
+		log.Printf("%s is outdated, its interfaces are the same", filename)
+		return exitOutdated, nil
+	}
+
+	exitCode := exitOutdated
+	for _, c := range changes {
+		log.Printf("%s: %s", filename, c)
+		if c.Breaking() {
+			exitCode = exitBreaking
+		}
+	}
+
+	return exitCode, nil
+}
+
+// writeFileAtomically writes the code to a temporary file next to the
+// output and renames it, so the output is either replaced or left intact.
+func writeFileAtomically(fs afero.Fs, filename string, code []byte) error {
+	dir := filepath.Dir(filename)
+	if err := fs.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+
+	// the leading dot keeps the go tool away from it
+	tmp, err := afero.TempFile(fs, dir, "."+filepath.Base(filename)+".*")
+	if err != nil {
+		return err
+	}
+	defer fs.Remove(tmp.Name()) //nolint:errcheck // it is renamed on success
+
+	if _, err := tmp.Write(code); err != nil {
+		tmp.Close() //nolint:errcheck // the write error is reported
+		return fmt.Errorf("writing %s: %v", filename, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing %s: %v", filename, err)
+	}
+	if err := fs.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+
+	return fs.Rename(tmp.Name(), filename)
+}
+
+// outputImportPath returns an import path of the package in the output
+// directory, using the closest go.mod. It is empty outside of a module.
+func outputImportPath(fs afero.Fs, outputDir string) (string, error) {
+	dir, err := filepath.Abs(outputDir)
+	if err != nil {
+		return "", err
+	}
+
+	for moduleDir := dir; ; moduleDir = filepath.Dir(moduleDir) {
+		content, err := afero.ReadFile(fs, filepath.Join(moduleDir, "go.mod"))
+		if err == nil {
+			rel, err := filepath.Rel(moduleDir, dir)
+			if err != nil {
+				return "", err
+			}
+			return path.Join(modfile.ModulePath(content), filepath.ToSlash(rel)), nil
+		}
+
+		if filepath.Dir(moduleDir) == moduleDir {
+			return "", nil
+		}
+	}
+}
+
+// expandResponseFiles replaces the @file arguments following the program name
+// with the arguments the files have, one per line. The blank lines are
+// skipped and the files are not expanded further.
+func expandResponseFiles(fs afero.Fs, args []string) ([]string, error) {
+	if len(args) == 0 {
+		return args, nil
+	}
+
+	expanded := []string{args[0]}
+
+	for _, arg := range args[1:] {
+		if !strings.HasPrefix(arg, "@") {
+			expanded = append(expanded, arg)
+			continue
+		}
+
+		content, err := afero.ReadFile(fs, arg[1:])
+		if err != nil {
+			return nil, fmt.Errorf("reading the response file: %v", err)
+		}
+
+		for _, line := range strings.Split(string(content), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				expanded = append(expanded, line)
+			}
+		}
+	}
+
+	return expanded, nil
+}
+
+var (
+	// osFs is the file system the helpers work on outside of the tests
+	osFs   = afero.NewOsFs()
+	finder = newSourceFilesFinder()
+)
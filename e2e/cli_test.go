@@ -1,6 +1,8 @@
 package e2e
 
 import (
+	"archive/zip"
+	"bytes"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -23,7 +25,7 @@ func TestBinary(t *testing.T) {
 	wd, _ := os.Getwd()
 	modcache := filepath.Join(wd, ".modcache")
 
-	path := "../cmd/ifacemaker/main.go"
+	path := "../cmd/ifacemaker"
 	binary := filepath.Join(wd, "ifacemaker")
 
 	// 1. build binary
@@ -100,6 +102,97 @@ func TestBinary(t *testing.T) {
 	}
 }
 
+func TestCheckSkipUnchanged(t *testing.T) {
+	wd, _ := os.Getwd()
+	modcache := filepath.Join(wd, ".modcache")
+
+	binary := filepath.Join(t.TempDir(), "ifacemaker")
+	build, err := exec.Command("go", "build", "-o", binary, "../cmd/ifacemaker").CombinedOutput()
+	require.NoErrorf(t, err, "unable to build binary: %s", string(build))
+
+	spec := testReadFile(t, filepath.Join("testdata", "01_audit", "case.yml"))
+	var test testCase
+	testUnmarshalYaml(t, spec, &test)
+	testGetPackage(t, test.Module, modcache)
+
+	output := filepath.Join(t.TempDir(), "audit.go")
+	run := func(extra ...string) (string, error) {
+		cmd := exec.Command(binary, append([]string{
+			"--source-pkg", test.Module,
+			"--module-path", test.ModulePath,
+			"--result-pkg", test.OutPackageName,
+			"--struct-name", test.StructName,
+			"--interface-name", test.InterfaceName,
+			"--output", output,
+			"--skip-unchanged",
+		}, extra...)...)
+		cmd.Env = append(os.Environ(), "GOMODCACHE="+modcache)
+		out, err := cmd.CombinedOutput()
+		return string(out), err
+	}
+
+	out, err := run()
+	require.NoErrorf(t, err, "cmd output: %s", out)
+
+	// act
+	out, err = run("--check")
+
+	// assert
+	require.NoErrorf(t, err, "cmd output: %s", out)
+	require.Contains(t, out, "is up to date")
+}
+
+func TestSourceArchiveCleanup(t *testing.T) {
+	binary := filepath.Join(t.TempDir(), "ifacemaker")
+	build, err := exec.Command("go", "build", "-o", binary, "../cmd/ifacemaker").CombinedOutput()
+	require.NoErrorf(t, err, "unable to build binary: %s", string(build))
+
+	archive := filepath.Join(t.TempDir(), "v1.2.0.zip")
+	testWriteZip(t, archive, map[string]string{
+		"github.com/acme/lib@v1.2.0/go.mod":           "module github.com/acme/lib\n\ngo 1.20\n",
+		"github.com/acme/lib@v1.2.0/client/client.go": "package client\n\ntype Client struct{}\n",
+	})
+
+	tmp := t.TempDir()
+	cmd := exec.Command(binary,
+		"--source-pkg", "github.com/acme/lib@v1.2.0",
+		"--source-dir", archive,
+		"--module-path", "client",
+		"--result-pkg", "mocks",
+		"--struct-name", "Client",
+		"--interface-name", "Client",
+		"--source-file", "missing.go",
+		"--output", filepath.Join(t.TempDir(), "client.go"),
+	)
+	cmd.Env = append(os.Environ(), "TMPDIR="+tmp)
+
+	// act
+	out, err := cmd.CombinedOutput()
+
+	// assert
+	require.Errorf(t, err, "cmd output: %s", string(out))
+	extracted, err := os.ReadDir(tmp)
+	require.NoError(t, err)
+	require.Empty(t, extracted, "the extracted archive is left behind")
+}
+
+func testWriteZip(t *testing.T, filename string, files map[string]string) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+
+	for name, content := range files {
+		f, err := w.Create(name)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(content))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, w.Close())
+	require.NoError(t, os.WriteFile(filename, buf.Bytes(), 0644))
+}
+
 func encodeFiles(files []string, modpath string) []string {
 	result := make([]string, len(files))
 	for i, f := range files {
@@ -0,0 +1,61 @@
+package generator
+
+import (
+	"errors"
+	"go/ast"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// substituteUnsupported replaces the type expressions of the signatures which
+// ParseType doesn't support with any, reporting each one, so the rest of the
+// methods are still generated.
+func (p *sourcePackage) substituteUnsupported(options Options) {
+	substitute := func(list *ast.FieldList) {
+		for _, field := range extractList(list) {
+			p.substituteField(options, field)
+		}
+	}
+
+	for _, f := range p.files {
+		ast.Inspect(f, func(node ast.Node) bool {
+			switch n := node.(type) {
+			case *ast.FuncType:
+				substitute(n.Params)
+				substitute(n.Results)
+			case *ast.TypeSpec:
+				substitute(n.TypeParams)
+			}
+			return true
+		})
+	}
+}
+
+func (p *sourcePackage) substituteField(options Options, field *ast.Field) {
+	for {
+		_, err := Parse(field, p.types, p.name)
+
+		var unsupported *UnsupportedTypeError
+		if !errors.As(err, &unsupported) {
+			return
+		}
+
+		options.warnf("%s: unsupported type expression %T is replaced with any", p.fileSet.Position(unsupported.Node.Pos()), unsupported.Node)
+
+		field.Type = astutil.Apply(field.Type, func(c *astutil.Cursor) bool {
+			if c.Node() == unsupported.Node {
+				c.Replace(anyExpr(options, unsupported.Node))
+				return false
+			}
+			return true
+		}, nil).(ast.Expr)
+	}
+}
+
+// anyExpr is spelled as interface{} before go1.18.
+func anyExpr(options Options, node ast.Node) ast.Expr {
+	if !options.supportsGenerics() {
+		return &ast.InterfaceType{Interface: node.Pos(), Methods: &ast.FieldList{}}
+	}
+	return &ast.Ident{Name: "any", NamePos: node.Pos()}
+}
@@ -0,0 +1,187 @@
+package generator
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"sort"
+)
+
+// ChangeKind tells how a method of an interface differs between two files.
+type ChangeKind int
+
+const (
+	ChangeAdded ChangeKind = iota + 1
+	ChangeRemoved
+	ChangeChanged
+)
+
+// Change is a difference of the interfaces declared in two files. The
+// Method is empty when the whole interface or func type is added or removed.
+type Change struct {
+	Interface string
+	Method    string
+	Kind      ChangeKind
+
+	// the signatures the method has in the files
+	Old, New string
+}
+
+// Breaking reports whether the code using the old interface may not compile
+// with the new one, the added methods and interfaces are not breaking.
+func (c Change) Breaking() bool {
+	return c.Kind != ChangeAdded
+}
+
+func (c Change) String() string {
+	if c.Method == "" {
+		switch c.Kind {
+		case ChangeAdded:
+			return "added " + c.Interface
+		case ChangeRemoved:
+			return "removed " + c.Interface
+		default:
+			return fmt.Sprintf("changed %s: %s -> %s", c.Interface, c.Old, c.New)
+		}
+	}
+
+	switch c.Kind {
+	case ChangeAdded:
+		return fmt.Sprintf("added %s.%s", c.Interface, c.Method)
+	case ChangeRemoved:
+		return fmt.Sprintf("removed %s.%s", c.Interface, c.Method)
+	default:
+		return fmt.Sprintf("changed %s.%s: %s -> %s", c.Interface, c.Method, c.Old, c.New)
+	}
+}
+
+// CompareInterfaces compares the interfaces and the func types of the old
+// code, a file generated earlier, with the ones of the new code the same way
+// AddedSince compares the methods: by the types of the params and results,
+// their names aside. The changes are ordered by the interface and method.
+func CompareInterfaces(oldCode, newCode []byte) ([]Change, error) {
+	oldSignatures, err := interfaceSignatures(oldCode)
+	if err != nil {
+		return nil, fmt.Errorf("parsing the old code: %v", err)
+	}
+
+	newSignatures, err := interfaceSignatures(newCode)
+	if err != nil {
+		return nil, fmt.Errorf("parsing the new code: %v", err)
+	}
+
+	var changes []Change
+
+	for name, oldMethods := range oldSignatures {
+		newMethods, ok := newSignatures[name]
+		if !ok {
+			changes = append(changes, Change{Interface: name, Kind: ChangeRemoved})
+			continue
+		}
+
+		for method, oldSignature := range oldMethods {
+			newSignature, ok := newMethods[method]
+			switch {
+			case !ok:
+				changes = append(changes, Change{Interface: name, Method: method, Kind: ChangeRemoved, Old: oldSignature})
+			case newSignature != oldSignature:
+				changes = append(changes, Change{Interface: name, Method: method, Kind: ChangeChanged, Old: oldSignature, New: newSignature})
+			}
+		}
+
+		for method, newSignature := range newMethods {
+			if _, ok := oldMethods[method]; !ok {
+				changes = append(changes, Change{Interface: name, Method: method, Kind: ChangeAdded, New: newSignature})
+			}
+		}
+	}
+
+	for name := range newSignatures {
+		if _, ok := oldSignatures[name]; !ok {
+			changes = append(changes, Change{Interface: name, Kind: ChangeAdded})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Interface != changes[j].Interface {
+			return changes[i].Interface < changes[j].Interface
+		}
+		return changes[i].Method < changes[j].Method
+	})
+
+	return changes, nil
+}
+
+// interfaceSignatures maps the interfaces and the func types declared in the
+// code to the signatures of their methods. The embedded interfaces are keyed
+// by themselves and a func type has a single method of no name.
+func interfaceSignatures(code []byte) (map[string]map[string]string, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), "", code, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	signatures := make(map[string]map[string]string)
+
+	for _, decl := range file.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			continue
+		}
+
+		for _, spec := range genDecl.Specs {
+			typeSpec := spec.(*ast.TypeSpec)
+			name := typeSpec.Name.Name
+
+			switch t := typeSpec.Type.(type) {
+			case *ast.FuncType:
+				signature, err := funcSignature("", t)
+				if err != nil {
+					return nil, err
+				}
+				signatures[name] = map[string]string{"": signature}
+			case *ast.InterfaceType:
+				methods := make(map[string]string)
+
+				for _, field := range extractList(t.Methods) {
+					if len(field.Names) == 0 {
+						embedded := types.ExprString(field.Type)
+						methods[embedded] = embedded
+						continue
+					}
+
+					funcType, ok := field.Type.(*ast.FuncType)
+					if !ok {
+						continue
+					}
+
+					signature, err := funcSignature(field.Names[0].Name, funcType)
+					if err != nil {
+						return nil, err
+					}
+					methods[field.Names[0].Name] = signature
+				}
+
+				signatures[name] = methods
+			}
+		}
+	}
+
+	return signatures, nil
+}
+
+func funcSignature(name string, funcType *ast.FuncType) (string, error) {
+	params, err := ParseMany(extractList(funcType.Params), nil, "")
+	if err != nil {
+		return "", err
+	}
+
+	results, err := ParseMany(extractList(funcType.Results), nil, "")
+	if err != nil {
+		return "", err
+	}
+
+	return Receiver{Name: name, Params: params, Results: results}.signature(), nil
+}
@@ -0,0 +1,120 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareInterfaces(t *testing.T) {
+	const committed = `package mocks
+
+import "context"
+
+type Client interface {
+	// Get returns the item.
+	Get(ctx context.Context, key string) (string, error)
+	Delete(ctx context.Context, key string) error
+}
+
+type Handler func(ctx context.Context) error
+`
+
+	cases := []struct {
+		name      string
+		generated string
+		want      []Change
+		breaking  bool
+	}{
+		{
+			name: "docs and param names only",
+			generated: `package mocks
+
+import "context"
+
+type Client interface {
+	Get(c context.Context, id string) (value string, err error)
+	// Delete drops the item.
+	Delete(c context.Context, id string) error
+}
+
+type Handler func(c context.Context) error
+`,
+		},
+		{
+			name: "additive",
+			generated: `package mocks
+
+import "context"
+
+type Client interface {
+	Get(ctx context.Context, key string) (string, error)
+	Delete(ctx context.Context, key string) error
+	Keys(ctx context.Context) ([]string, error)
+}
+
+type Handler func(ctx context.Context) error
+
+type Store interface {
+	Close() error
+}
+`,
+			want: []Change{
+				{Interface: "Client", Method: "Keys", Kind: ChangeAdded, New: "Keys(context.Context)([]string, error)"},
+				{Interface: "Store", Kind: ChangeAdded},
+			},
+		},
+		{
+			name: "breaking",
+			generated: `package mocks
+
+import "context"
+
+type Client interface {
+	Get(ctx context.Context, key string, version int) (string, error)
+	Keys(ctx context.Context) ([]string, error)
+}
+`,
+			want: []Change{
+				{Interface: "Client", Method: "Delete", Kind: ChangeRemoved, Old: "Delete(context.Context, string)(error)"},
+				{
+					Interface: "Client",
+					Method:    "Get",
+					Kind:      ChangeChanged,
+					Old:       "Get(context.Context, string)(string, error)",
+					New:       "Get(context.Context, string, int)(string, error)",
+				},
+				{Interface: "Client", Method: "Keys", Kind: ChangeAdded, New: "Keys(context.Context)([]string, error)"},
+				{Interface: "Handler", Kind: ChangeRemoved},
+			},
+			breaking: true,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			// act
+			got, err := CompareInterfaces([]byte(committed), []byte(tc.generated))
+
+			// assert
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+
+			var breaking bool
+			for _, c := range got {
+				breaking = breaking || c.Breaking()
+			}
+			require.Equal(t, tc.breaking, breaking)
+		})
+	}
+
+	t.Run("invalid old code", func(t *testing.T) {
+		// act
+		_, err := CompareInterfaces([]byte("type Client interface{}"), []byte(committed))
+
+		// assert
+		require.ErrorContains(t, err, "parsing the old code: ")
+	})
+}
@@ -0,0 +1,571 @@
+package generator
+
+import (
+	"fmt"
+	"go/ast"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	MethodSetPointer = "pointer"
+	MethodSetValue   = "value"
+)
+
+// methodCollector collects a method set of a type including
+// the methods promoted from the embedded fields.
+type methodCollector struct {
+	options Options
+	cache   *packageCache
+
+	// types which method sets are being collected,
+	// so a cyclic embedding stops the recursion
+	visiting map[typeKey]struct{}
+}
+
+type typeKey struct {
+	pkg  *sourcePackage
+	name string
+}
+
+func newMethodCollector(options Options) *methodCollector {
+	cache := options.cache
+	if cache == nil {
+		cache = newPackageCache()
+	}
+
+	return &methodCollector{
+		options:  options,
+		cache:    cache,
+		visiting: make(map[typeKey]struct{}),
+	}
+}
+
+// method is a receiver with the depth of embedding it is promoted from,
+// zero stands for the methods declared on the type itself.
+type method struct {
+	Receiver
+	depth int
+}
+
+func (c *methodCollector) collect(pkg *sourcePackage, typeName string) ([]method, error) {
+	// the methods declared on the aliases belong to the aliased type
+	typeName = pkg.resolveAlias(typeName)
+	isType := func(name string) bool {
+		return pkg.resolveAlias(name) == typeName
+	}
+
+	key := typeKey{pkg: pkg, name: typeName}
+	if _, ok := c.visiting[key]; ok {
+		c.options.warnf("cyclic embedding of %s.%s, its methods are not promoted again", pkg.name, typeName)
+		return nil, nil
+	}
+
+	c.visiting[key] = struct{}{}
+	defer delete(c.visiting, key)
+
+	// the other packages can't have them in their interfaces
+	unexported := c.options.IncludeUnexported && pkg.importPath == sourceImportPath(c.options)
+
+	var own, embedded []method
+
+	for _, f := range pkg.files {
+		receivers, err := parseReceivers(f, pkg.fileSet, isType, unexported, pkg.name, pkg.types)
+		if err != nil {
+			return nil, err
+		}
+
+		var methods []method
+		for _, r := range receivers {
+			methods = append(methods, method{Receiver: r})
+		}
+
+		resolveImportPaths(methods, f, pkg)
+		own = append(own, methods...)
+	}
+
+	if c.options.PreserveOrder {
+		sortBySource(pkg, own)
+	}
+
+	spec, file := pkg.lookupType(typeName)
+	if spec == nil {
+		return c.prepare(pkg, own), nil
+	}
+
+	// the receivers may name the type parameters differently,
+	// func (c *Cache[Key, Value]) of type Cache[K, V any], while
+	// the promotion substitutes the names of the declaration
+	if names := typeParamNames(spec); len(names) > 0 {
+		for _, m := range own {
+			if len(m.typeParams) != len(names) {
+				continue
+			}
+
+			idents := make([]*Type, len(names))
+			for i, name := range names {
+				idents[i] = &Type{Name: name, Kind: TypeKindIdent}
+			}
+			substituteTypeParams([]method{m}, m.typeParams, idents)
+		}
+	}
+
+	// the fields of type AdminClient Client promote their methods,
+	// the ones declared on Client are not in the method set
+	spec, file = pkg.underlyingType(spec, file)
+
+	switch t := spec.Type.(type) {
+	case *ast.StructType:
+		fields := make(map[string]struct{})
+
+		for _, field := range extractList(t.Fields) {
+			if len(field.Names) > 0 {
+				for _, name := range field.Names {
+					fields[name.Name] = struct{}{}
+				}
+				continue
+			}
+
+			fields[embeddedFieldName(field.Type)] = struct{}{}
+
+			methods, err := c.collectEmbedded(pkg, file, field.Type)
+			if err != nil {
+				return nil, err
+			}
+
+			// a value of the struct holds the pointer,
+			// so it has every method of the field
+			if _, ok := field.Type.(*ast.StarExpr); ok {
+				for i := range methods {
+					methods[i].pointer = false
+				}
+			}
+
+			embedded = append(embedded, methods...)
+		}
+
+		// a field shadows the promoted methods of the same name,
+		// they are not in the method set of the struct
+		embedded = dropShadowed(embedded, fields)
+
+		return mergeMethodSets(c.prepare(pkg, own), embedded, true), nil
+	case *ast.InterfaceType:
+		var declared []method
+
+		for _, field := range extractList(t.Methods) {
+			if len(field.Names) == 0 {
+				methods, err := c.collectEmbedded(pkg, file, field.Type)
+				if err != nil {
+					return nil, err
+				}
+				embedded = append(embedded, methods...)
+				continue
+			}
+
+			funcType, ok := field.Type.(*ast.FuncType)
+			if !ok || (!field.Names[0].IsExported() && !unexported) {
+				continue
+			}
+
+			params, err := ParseMany(extractList(funcType.Params), pkg.types, pkg.name)
+			if err != nil {
+				return nil, positionError(pkg.fileSet, err)
+			}
+
+			results, err := ParseMany(extractList(funcType.Results), pkg.types, pkg.name)
+			if err != nil {
+				return nil, positionError(pkg.fileSet, err)
+			}
+
+			doc, directives, err := splitDirectives(pkg.fileSet, extractComments(field.Doc))
+			if err != nil {
+				return nil, err
+			}
+
+			declared = append(declared, method{Receiver: Receiver{
+				Comment: parseReceiverDocs(doc),
+				Params:  params,
+				Results: results,
+				Name:    field.Names[0].Name,
+				skip:    directives.skip,
+				rename:  directives.rename,
+			}})
+		}
+
+		resolveImportPaths(declared, file, pkg)
+		own = append(own, declared...)
+
+		// interfaces may embed the same method several times
+		return mergeMethodSets(c.prepare(pkg, own), embedded, false), nil
+	}
+
+	return c.prepare(pkg, own), nil
+}
+
+// sortBySource orders the methods by the file they are declared in and
+// their position there, so the order doesn't depend on the one of the files.
+func sortBySource(pkg *sourcePackage, methods []method) {
+	sort.SliceStable(methods, func(i, j int) bool {
+		a, b := pkg.fileSet.Position(methods[i].pos), pkg.fileSet.Position(methods[j].pos)
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		return a.Offset < b.Offset
+	})
+}
+
+// prepare adjusts the methods declared in the package
+// before they get merged into the method set.
+func (c *methodCollector) prepare(pkg *sourcePackage, methods []method) []method {
+	if c.options.ReplaceUnexportedWith != "" {
+		for _, m := range methods {
+			replaceUnexported(m.Receiver, pkg.unexported, c.options.ReplaceUnexportedWith)
+		}
+	}
+
+	switch {
+	case !c.options.supportsGenerics():
+		// any is not there yet
+		for _, m := range methods {
+			useInterface(m.Receiver)
+		}
+	case c.options.UseAny:
+		for _, m := range methods {
+			useAny(m.Receiver)
+		}
+	}
+
+	return methods
+}
+
+func (c *methodCollector) collectEmbedded(pkg *sourcePackage, file *ast.File, expr ast.Expr) ([]method, error) {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+
+	// the type arguments of Cache[pkg.Key, pkg.Value]
+	var typeArgs []ast.Expr
+	switch e := expr.(type) {
+	case *ast.IndexExpr:
+		expr, typeArgs = e.X, []ast.Expr{e.Index}
+	case *ast.IndexListExpr:
+		expr, typeArgs = e.X, e.Indices
+	}
+
+	var (
+		methods []method
+		err     error
+
+		// the package declaring the embedded type
+		declaring = pkg
+		typeName  string
+	)
+
+	switch e := expr.(type) {
+	case *ast.Ident:
+		// the package may declare a type of its own named error
+		if spec, _ := pkg.lookupType(e.Name); spec == nil && e.Name == "error" {
+			methods = []method{{Receiver: errorReceiver()}}
+			break
+		}
+		typeName = e.Name
+		methods, err = c.collect(pkg, e.Name)
+	case *ast.SelectorExpr:
+		// methods can't be promoted from the other
+		// packages unless there is a way to find them
+		if c.options.ResolvePackage == nil {
+			return nil, nil
+		}
+
+		name, ok := e.X.(*ast.Ident)
+		if !ok {
+			return nil, positionError(pkg.fileSet, &UnsupportedTypeError{Node: e})
+		}
+
+		typeName = e.Sel.Name
+		declaring, err = c.importedPackage(file, name.Name)
+		if err != nil {
+			return nil, err
+		}
+		methods, err = c.collect(declaring, e.Sel.Name)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(typeArgs) > 0 && len(methods) > 0 {
+		if err := c.instantiate(pkg, file, declaring, typeName, typeArgs, methods); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range methods {
+		methods[i].depth++
+	}
+
+	return methods, nil
+}
+
+// instantiate substitutes the type parameters of the generic type declared
+// in the declaring package with the type arguments of the embedding file in
+// the promoted methods. The arguments qualified with a package, pkg.Key, get
+// its import path, the way the types of the methods do.
+func (c *methodCollector) instantiate(
+	pkg *sourcePackage,
+	file *ast.File,
+	declaring *sourcePackage,
+	typeName string,
+	typeArgs []ast.Expr,
+	methods []method,
+) error {
+	spec, _ := declaring.lookupType(declaring.resolveAlias(typeName))
+	if spec == nil {
+		return nil
+	}
+
+	names := typeParamNames(spec)
+	if len(names) != len(typeArgs) {
+		return nil
+	}
+
+	params, err := ParseMany(typeArgFields(typeArgs), pkg.types, pkg.name)
+	if err != nil {
+		return positionError(pkg.fileSet, err)
+	}
+
+	args := []method{{Receiver: Receiver{Params: params}}}
+	resolveImportPaths(args, file, pkg)
+	c.prepare(pkg, args)
+
+	types := make([]*Type, len(params))
+	for i, p := range params {
+		types[i] = p.Type
+	}
+
+	substituteTypeParams(methods, names, types)
+
+	return nil
+}
+
+// typeArgFields wraps the type arguments into fields, so they
+// are parsed along with the parameters of the methods.
+func typeArgFields(typeArgs []ast.Expr) []*ast.Field {
+	fields := make([]*ast.Field, len(typeArgs))
+	for i, arg := range typeArgs {
+		fields[i] = &ast.Field{Type: arg}
+	}
+	return fields
+}
+
+// typeParamNames returns the names of the type parameters of the type spec.
+func typeParamNames(spec *ast.TypeSpec) []string {
+	var names []string
+	for _, field := range extractList(spec.TypeParams) {
+		for _, name := range field.Names {
+			names = append(names, name.Name)
+		}
+	}
+	return names
+}
+
+// substituteTypeParams replaces the type parameters, the unqualified
+// types of the names, in the signatures of the methods with the copies
+// of the types.
+func substituteTypeParams(methods []method, names []string, types []*Type) {
+	byName := make(map[string]*Type, len(names))
+	for i, name := range names {
+		byName[name] = types[i]
+	}
+
+	// found first, so the substituted types aren't substituted again:
+	// the parameters may be swapped, Cache[V, K]
+	var found []*Type
+	for _, m := range methods {
+		walkImportTypes(nil, []Receiver{m.Receiver}, func(t *Type) {
+			if _, ok := byName[t.Name]; ok && t.Kind == TypeKindIdent && t.Package == "" {
+				found = append(found, t)
+			}
+		})
+	}
+
+	for _, t := range found {
+		*t = *byName[t.Name].clone()
+	}
+}
+
+// importedPackage finds a package imported by the file under the given name.
+func (c *methodCollector) importedPackage(file *ast.File, name string) (*sourcePackage, error) {
+	var unnamed []string
+
+	for _, spec := range file.Imports {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		if spec.Name != nil {
+			if spec.Name.Name == name {
+				return c.loadPackage(importPath)
+			}
+			continue
+		}
+
+		if guessPackageName(importPath) == name {
+			return c.loadPackage(importPath)
+		}
+
+		unnamed = append(unnamed, importPath)
+	}
+
+	// a declared package name doesn't have to match its path,
+	// so the only way to find out is to look inside
+	for _, importPath := range unnamed {
+		pkg, err := c.loadPackage(importPath)
+		if err == nil && pkg.name == name {
+			return pkg, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unable to find an import of package %s", name)
+}
+
+func (c *methodCollector) loadPackage(importPath string) (*sourcePackage, error) {
+	if pkg, ok := c.cache.imports[importPath]; ok {
+		return pkg, nil
+	}
+
+	files, err := c.options.ResolvePackage(importPath)
+	if err != nil {
+		return nil, fmt.Errorf("resolving package %s: %v", importPath, err)
+	}
+
+	pkg, err := parsePackageWith(c.cache.readFile, importPath, files)
+	if err != nil {
+		return nil, fmt.Errorf("parsing package %s: %v", importPath, err)
+	}
+
+	if c.options.BestEffort {
+		pkg.substituteUnsupported(c.options)
+	}
+
+	c.cache.imports[importPath] = pkg
+
+	return pkg, nil
+}
+
+var majorVersionRe = regexp.MustCompile(`^v\d+$`)
+
+// guessPackageName returns a package name conventionally used for
+// the import path the same way goimports does it:
+// github.com/foo/go-bar/v2 -> bar, gopkg.in/yaml.v2 -> yaml.
+func guessPackageName(importPath string) string {
+	base := path.Base(importPath)
+	if majorVersionRe.MatchString(base) {
+		base = path.Base(path.Dir(importPath))
+	}
+
+	base = strings.TrimPrefix(base, "go-")
+
+	notIdentifier := func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	}
+	if i := strings.IndexFunc(base, notIdentifier); i >= 0 {
+		base = base[:i]
+	}
+
+	return base
+}
+
+// embeddedFieldName is the name of the field the type is embedded as:
+// *pkg.Store and Store[K] are both named Store.
+func embeddedFieldName(expr ast.Expr) string {
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.ParenExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		case *ast.SelectorExpr:
+			return e.Sel.Name
+		case *ast.Ident:
+			return e.Name
+		default:
+			return ""
+		}
+	}
+}
+
+func dropShadowed(methods []method, fields map[string]struct{}) []method {
+	var result []method
+
+	for _, m := range methods {
+		if _, ok := fields[m.Name]; !ok {
+			result = append(result, m)
+		}
+	}
+
+	return result
+}
+
+// mergeMethodSets adds the promoted methods to the type's own ones.
+// A shallower method shadows the deeper ones and, when ambiguous
+// is set, a name promoted several times at the same depth is
+// excluded as Go does for the struct fields.
+func mergeMethodSets(own, embedded []method, ambiguous bool) []method {
+	seen := make(map[string]struct{}, len(own))
+	for _, m := range own {
+		seen[m.Name] = struct{}{}
+	}
+
+	minDepth := make(map[string]int)
+	count := make(map[string]int)
+
+	for _, m := range embedded {
+		if _, ok := seen[m.Name]; ok {
+			continue
+		}
+
+		d, ok := minDepth[m.Name]
+		switch {
+		case !ok || m.depth < d:
+			minDepth[m.Name] = m.depth
+			count[m.Name] = 1
+		case m.depth == d:
+			count[m.Name]++
+		}
+	}
+
+	result := own
+
+	for _, m := range embedded {
+		if _, ok := seen[m.Name]; ok {
+			continue
+		}
+		if m.depth != minDepth[m.Name] || (ambiguous && count[m.Name] > 1) {
+			continue
+		}
+
+		seen[m.Name] = struct{}{}
+		result = append(result, m)
+	}
+
+	return result
+}
+
+func errorReceiver() Receiver {
+	return Receiver{
+		Name: "Error",
+		Results: []*Param{{
+			Type: &Type{Name: "string", Kind: TypeKindIdent},
+		}},
+	}
+}
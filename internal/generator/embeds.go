@@ -0,0 +1,156 @@
+package generator
+
+import (
+	"fmt"
+	"go/ast"
+)
+
+// Embed is an interface embedded in the generated one,
+// io.Closer or github.com/acme/lib/store.Store.
+type Embed struct {
+	ImportPath string
+	Name       string
+}
+
+func (e Embed) String() string {
+	return guessPackageName(e.ImportPath) + "." + e.Name
+}
+
+// flag is the --embed value the interface is referred by.
+func (e Embed) flag() string {
+	return e.ImportPath + "." + e.Name
+}
+
+type embeddedMethod struct {
+	Receiver
+	embed Embed
+}
+
+// embeddedMethods returns the methods the embedded interfaces have by name.
+// Go allows the interfaces to overlap as long as the signatures are the same.
+func (c *methodCollector) embeddedMethods(embeds []Embed) (map[string]embeddedMethod, error) {
+	result := make(map[string]embeddedMethod)
+
+	for _, e := range embeds {
+		if c.options.ResolvePackage == nil {
+			return nil, fmt.Errorf("unable to find the methods of the embedded %s", e)
+		}
+
+		pkg, err := c.loadPackage(e.ImportPath)
+		if err != nil {
+			return nil, err
+		}
+
+		spec, _ := pkg.lookupType(e.Name)
+		if spec == nil {
+			return nil, fmt.Errorf("embedded interface %s is not found in %s", e.Name, e.ImportPath)
+		}
+		if _, ok := spec.Type.(*ast.InterfaceType); !ok {
+			return nil, fmt.Errorf("embedded %s is not an interface", e)
+		}
+
+		methods, err := c.collect(pkg, e.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := addEmbedded(result, e, methods); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// addEmbedded adds the methods of the embedded interface by name.
+func addEmbedded(embedded map[string]embeddedMethod, e Embed, methods []method) error {
+	for _, m := range methods {
+		if other, ok := embedded[m.Name]; ok && other.signature() != m.signature() {
+			return fmt.Errorf("embedded %s and %s have method %s with different signatures", other.embed, e, m.Name)
+		}
+
+		embedded[m.Name] = embeddedMethod{Receiver: m.Receiver, embed: e}
+	}
+
+	return nil
+}
+
+// existingInterface finds the exported interface of the package the receivers
+// implement, the one with the most methods, so it can be embedded instead of
+// listing them again. No methods are returned if there is none.
+func (c *methodCollector) existingInterface(pkg *sourcePackage, receivers []Receiver) (Embed, []method, error) {
+	signatures := make(map[string]struct{}, len(receivers))
+	for _, r := range receivers {
+		signatures[r.signature()] = struct{}{}
+	}
+
+	var (
+		found   Embed
+		methods []method
+	)
+
+	for _, t := range listTypes(pkg) {
+		if t.Kind != TypeKindInterface {
+			continue
+		}
+
+		// the interface generated into the source package is not its own part
+		if c.options.intoSourcePackage() && t.Name == c.options.InterfaceName {
+			continue
+		}
+
+		// a generic interface can't be embedded without the type arguments
+		if spec, _ := pkg.lookupType(t.Name); spec.TypeParams != nil {
+			continue
+		}
+
+		candidate, err := c.collect(pkg, t.Name)
+		if err != nil {
+			return Embed{}, nil, err
+		}
+
+		if len(candidate) <= len(methods) || !implements(signatures, candidate) {
+			continue
+		}
+
+		found, methods = Embed{ImportPath: pkg.importPath, Name: t.Name}, candidate
+	}
+
+	return found, methods, nil
+}
+
+func implements(signatures map[string]struct{}, methods []method) bool {
+	for _, m := range methods {
+		if _, ok := signatures[m.signature()]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// checkEmbedded fails if a struct method has a different signature than
+// the method of the same name an embedded interface has.
+func checkEmbedded(receivers []Receiver, embedded map[string]embeddedMethod) error {
+	for _, r := range receivers {
+		m, ok := embedded[r.Name]
+		if ok && m.signature() != r.signature() {
+			return fmt.Errorf("method %s has a different signature than the one of the embedded %s", r.Name, m.embed)
+		}
+	}
+
+	return nil
+}
+
+// dedupEmbedded drops the receivers the embedded interfaces already have.
+func dedupEmbedded(receivers []Receiver, embedded map[string]embeddedMethod) []Receiver {
+	var result []Receiver
+
+	for _, r := range receivers {
+		if _, ok := embedded[r.Name]; !ok {
+			result = append(result, r)
+		}
+	}
+
+	return result
+}
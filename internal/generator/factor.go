@@ -0,0 +1,127 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// factoredMethods are the methods GenerateAll found in every interface of
+// the structs, of the same name and signature, see FactorCommon.
+type factoredMethods struct {
+	// the names of the methods in the interfaces
+	names map[string]struct{}
+
+	// the structs which interfaces embed the common one
+	structs []string
+
+	// only keeps the common methods, the FactorCommon interface is
+	// generated, the others drop them and embed it instead
+	only bool
+}
+
+// embeds reports whether the interface of the struct embeds the common one.
+func (f *factoredMethods) embeds(structName string) bool {
+	if f == nil {
+		return false
+	}
+
+	for _, s := range f.structs {
+		if s == structName {
+			return true
+		}
+	}
+
+	return false
+}
+
+// common reports whether the FactorCommon interface is generated.
+func (f *factoredMethods) common() bool {
+	return f != nil && f.only
+}
+
+// split keeps the common receivers or the rest of them.
+func (f *factoredMethods) split(receivers []Receiver) []Receiver {
+	var kept []Receiver
+
+	for _, r := range receivers {
+		if _, common := f.names[r.Name]; common == f.only {
+			kept = append(kept, r)
+		}
+	}
+
+	return kept
+}
+
+// doc is the comment of the FactorCommon interface.
+func (f *factoredMethods) doc(name string) string {
+	return "// " + name + " has the methods common to " + strings.Join(f.structs, ", ") + ".\n"
+}
+
+// factorCommon generates the interfaces of the structs to find the methods
+// they all have, compared by the types of the params and results like
+// CompareInterfaces does. The generic structs are left out, their methods
+// refer to the type parameters. Nil is returned if there are less than two
+// interfaces or no method is common to them.
+func factorCommon(options Options, pkg *sourcePackage, structs []string) (*factoredMethods, error) {
+	var (
+		common map[string]string
+		having []string
+	)
+
+	for _, name := range structs {
+		if spec, _ := pkg.lookupType(name); spec != nil && spec.TypeParams != nil {
+			continue
+		}
+
+		structOptions, err := allStructOptions(options, name, name)
+		if err != nil {
+			return nil, err
+		}
+		// the generation itself reports them
+		structOptions.Warn = nil
+
+		code, err := Generate(structOptions)
+		if errors.Is(err, errNoMethods) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("generating %s: %v", name, err)
+		}
+
+		signatures, err := interfaceSignatures(code)
+		if err != nil {
+			return nil, fmt.Errorf("parsing the interface of %s: %v", name, err)
+		}
+
+		having = append(having, name)
+		methods := signatures[name]
+
+		if common == nil {
+			common = make(map[string]string, len(methods))
+			for method, signature := range methods {
+				// the embedded interfaces are keyed by themselves
+				if method != signature {
+					common[method] = signature
+				}
+			}
+			continue
+		}
+
+		for method, signature := range common {
+			if methods[method] != signature {
+				delete(common, method)
+			}
+		}
+	}
+
+	if len(having) < 2 || len(common) == 0 {
+		return nil, nil
+	}
+
+	names := make(map[string]struct{}, len(common))
+	for method := range common {
+		names[method] = struct{}{}
+	}
+	return &factoredMethods{names: names, structs: having}, nil
+}
@@ -0,0 +1,117 @@
+package generator
+
+import (
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+const (
+	FilenameCaseSnake = "snake"
+	FilenameCaseKebab = "kebab"
+	FilenameCaseLower = "lower"
+)
+
+// Filename derives a Go file name from the interface name in the given case,
+// snake_case if it is empty: HTTPClient -> http_client.go, http-client.go
+// or httpclient.go.
+func Filename(name, nameCase string) string {
+	return nameInCase(name, nameCase) + ".go"
+}
+
+func nameInCase(name, nameCase string) string {
+	words := splitWords(name)
+	for i, w := range words {
+		words[i] = strings.ToLower(w)
+	}
+
+	switch nameCase {
+	case FilenameCaseKebab:
+		return strings.Join(words, "-")
+	case FilenameCaseLower:
+		return strings.Join(words, "")
+	default:
+		return strings.Join(words, "_")
+	}
+}
+
+// OutputVars are the variables of an output path template.
+type OutputVars struct {
+	Struct    string
+	Interface string
+	Package   string
+}
+
+// IsOutputTemplate reports whether the output path has template actions,
+// gen/{{.Interface | snake}}.go.
+func IsOutputTemplate(output string) bool {
+	return strings.Contains(output, "{{")
+}
+
+// ParseOutputTemplate parses an output path template, the snake, kebab and
+// lower functions put the names in the cases of the file names:
+// HTTPClient -> http_client, http-client and httpclient.
+func ParseOutputTemplate(output string) (*template.Template, error) {
+	funcs := template.FuncMap{
+		FilenameCaseSnake: func(name string) string { return nameInCase(name, FilenameCaseSnake) },
+		FilenameCaseKebab: func(name string) string { return nameInCase(name, FilenameCaseKebab) },
+		FilenameCaseLower: func(name string) string { return nameInCase(name, FilenameCaseLower) },
+	}
+
+	return template.New("output").Funcs(funcs).Parse(output)
+}
+
+// OutputPath executes the output path template.
+func OutputPath(tmpl *template.Template, vars OutputVars) (string, error) {
+	var b strings.Builder
+	if err := tmpl.Execute(&b, vars); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// PackageFilename turns the file name into a _test.go one for an external test
+// package, foo_test, as the go tool doesn't build the package from others.
+func PackageFilename(filename, packageName string) string {
+	if !strings.HasSuffix(packageName, "_test") || strings.HasSuffix(filename, "_test.go") {
+		return filename
+	}
+	return strings.TrimSuffix(filename, ".go") + "_test.go"
+}
+
+// splitWords splits a mixed caps name into words keeping the initialisms
+// together: HTTPClient -> HTTP, Client. Digits stick to the preceding word.
+func splitWords(name string) []string {
+	runes := []rune(name)
+
+	var (
+		words []string
+		start int
+	)
+
+	for i := 1; i < len(runes); i++ {
+		prev, cur := runes[i-1], runes[i]
+
+		// fooBar, Client4Bar
+		lowerToUpper := (unicode.IsLower(prev) || unicode.IsDigit(prev)) && unicode.IsUpper(cur)
+		// HTTPClient splits before the C
+		initialismEnd := unicode.IsUpper(prev) && unicode.IsUpper(cur) &&
+			i+1 < len(runes) && unicode.IsLower(runes[i+1])
+
+		if lowerToUpper || initialismEnd || cur == '_' {
+			if i > start {
+				words = append(words, string(runes[start:i]))
+			}
+			start = i
+			if cur == '_' {
+				start = i + 1
+			}
+		}
+	}
+
+	if start < len(runes) {
+		words = append(words, string(runes[start:]))
+	}
+
+	return words
+}
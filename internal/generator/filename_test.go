@@ -0,0 +1,105 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilename(t *testing.T) {
+	cases := []struct {
+		name     string
+		nameCase string
+		want     string
+	}{
+		{name: "Client", nameCase: FilenameCaseSnake, want: "client.go"},
+		{name: "UserStore", nameCase: FilenameCaseSnake, want: "user_store.go"},
+		{name: "HTTPClient", nameCase: FilenameCaseSnake, want: "http_client.go"},
+		{name: "Client4", nameCase: FilenameCaseSnake, want: "client4.go"},
+		{name: "APIV2Client", nameCase: FilenameCaseSnake, want: "apiv2_client.go"},
+		{name: "user_Store", nameCase: FilenameCaseSnake, want: "user_store.go"},
+		{name: "UserStore", nameCase: "", want: "user_store.go"},
+		{name: "UserStore", nameCase: FilenameCaseKebab, want: "user-store.go"},
+		{name: "HTTPClient", nameCase: FilenameCaseKebab, want: "http-client.go"},
+		{name: "UserStore", nameCase: FilenameCaseLower, want: "userstore.go"},
+		{name: "HTTPClient", nameCase: FilenameCaseLower, want: "httpclient.go"},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.nameCase+" "+tc.name, func(t *testing.T) {
+			// act
+			got := Filename(tc.name, tc.nameCase)
+
+			// assert
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestPackageFilename(t *testing.T) {
+	cases := []struct {
+		filename    string
+		packageName string
+		want        string
+	}{
+		{filename: "client.go", packageName: "mocks", want: "client.go"},
+		{filename: "client.go", packageName: "client_test", want: "client_test.go"},
+		{filename: "client_test.go", packageName: "client_test", want: "client_test.go"},
+		{filename: "testing.go", packageName: "test", want: "testing.go"},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.packageName+" "+tc.filename, func(t *testing.T) {
+			// act
+			got := PackageFilename(tc.filename, tc.packageName)
+
+			// assert
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestOutputPath(t *testing.T) {
+	vars := OutputVars{Struct: "HTTPClient", Interface: "HTTPDoer", Package: "mocks"}
+
+	cases := []struct {
+		output string
+		want   string
+	}{
+		{output: "gen/{{.Interface | lower}}.go", want: "gen/httpdoer.go"},
+		{output: "gen/{{.Interface | snake}}.go", want: "gen/http_doer.go"},
+		{output: "{{.Package}}/{{.Struct | kebab}}.go", want: "mocks/http-client.go"},
+		{output: "gen/{{.Struct}}.go", want: "gen/HTTPClient.go"},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.output, func(t *testing.T) {
+			tmpl, err := ParseOutputTemplate(tc.output)
+			require.NoError(t, err)
+
+			// act
+			got, err := OutputPath(tmpl, vars)
+
+			// assert
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+
+	t.Run("unknown variable", func(t *testing.T) {
+		tmpl, err := ParseOutputTemplate("gen/{{.Name}}.go")
+		require.NoError(t, err)
+
+		// act
+		_, err = OutputPath(tmpl, vars)
+
+		// assert
+		require.ErrorContains(t, err, "can't evaluate field Name")
+	})
+}
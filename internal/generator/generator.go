@@ -1,12 +1,33 @@
 package generator
 
 import (
+	"errors"
+	"fmt"
 	"go/ast"
-	"go/parser"
 	"go/token"
-	"os"
+	"io"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
 )
 
+// Role is an interface having a subset of the struct methods.
+type Role struct {
+	Name string
+
+	// Filter matches the names of the methods the interface has
+	Filter *regexp.Regexp
+}
+
+// Group is a banner comment the methods which names
+// start with the prefix are listed under.
+type Group struct {
+	Banner string
+	Prefix string
+}
+
 type Options struct {
 	Files             []string
 	StructName        string
@@ -15,89 +36,715 @@ type Options struct {
 	ModulePath        string
 	SourcePackage     string
 	OutputFilename    string
+	CopyTypeDoc       bool
+
+	// SourceImportPath is the path the source package is imported by,
+	// derived from SourcePackage and ModulePath if empty. It differs from
+	// the path the package is fetched by behind a vanity import path.
+	SourceImportPath string
+
+	// GroupImports writes an import block with the standard
+	// library packages grouped before the third-party ones.
+	GroupImports bool
+
+	// NoImports leaves the import block out, the qualified types are
+	// written as they are, for the snippets which imports are handled
+	// elsewhere. The result may not compile on its own.
+	NoImports bool
+
+	// SiblingFiles are the other files of the package, only
+	// used to resolve the types declared in there
+	SiblingFiles []string
+
+	// ReplaceUnexportedWith is a type (any, interface{} or a type of the
+	// output package) used in place of the unexported types of the source
+	// package, which can't be referenced from the generated interface.
+	ReplaceUnexportedWith string
+
+	// UseAny spells the empty interfaces as any.
+	UseAny bool
+
+	// GoVersion the result is going to be compiled with, so the
+	// features it lacks are not used. The latest one if empty.
+	GoVersion string
+
+	// SourceGoVersion is the go directive of the source module.
+	SourceGoVersion string
+
+	// OutputImportPath is an import path of the result package, used to
+	// check it can import the source one. The check is skipped if empty.
+	OutputImportPath string
+
+	// AnnotateSource writes the import path of the source
+	// struct to the interface doc, trimming the TrimPrefix.
+	AnnotateSource bool
+	TrimPrefix     string
+
+	// FilenameCase is the case of the file names derived from the
+	// interface names by GenerateAll, see Filename.
+	FilenameCase string
+
+	// OutputTemplate makes the paths of the files GenerateAll generates
+	// instead of the FilenameCase names in the OutputFilename directory,
+	// see ParseOutputTemplate.
+	OutputTemplate *template.Template
+
+	// FlagMissingContext marks the methods which first parameter
+	// is not a context.Context as deprecated.
+	FlagMissingContext bool
+
+	// Include and Exclude filter the methods declared on the struct by
+	// name, a method matching both is excluded. The promoted methods
+	// are only filtered with FilterPromoted.
+	Include        *regexp.Regexp
+	Exclude        *regexp.Regexp
+	FilterPromoted bool
+
+	// MethodSet is the method set of the struct the interface lists,
+	// MethodSetPointer, the default, has every method, MethodSetValue
+	// leaves out the ones declared on the pointer, so the values of the
+	// struct implement the interface too.
+	MethodSet string
+
+	// AddedSince is an older version of the source package, BaselineFiles
+	// are its files. Only the methods which signatures are not found in
+	// that version are generated.
+	AddedSince    string
+	BaselineFiles []string
+
+	// Embeds are the interfaces embedded in the generated one. The methods
+	// they have are not listed again unless EmbedNoDedup is set, the ones
+	// of the same name must have the same signatures. Finding them
+	// requires ResolvePackage.
+	Embeds       []Embed
+	EmbedNoDedup bool
+
+	// ReuseExistingInterface embeds the interface of the source package the
+	// struct implements, the one with the most methods, as if it was one of
+	// the Embeds. The struct methods are listed as is if there is none.
+	ReuseExistingInterface bool
+
+	// FuncType is a method of the struct a function type of its signature
+	// is generated for, named InterfaceName, instead of the interface.
+	FuncType string
+
+	// EmitStub adds a struct named after every interface with the Stub
+	// suffix, which methods do nothing but return the zero values.
+	EmitStub bool
+
+	// Roles are the interfaces generated instead of the InterfaceName one,
+	// each with the methods matching its filter.
+	Roles []Role
+
+	// Groups list the methods under the banners of the groups, ordered by
+	// the banner, a method belongs to the group of its longest prefix.
+	// The methods of no group are listed first.
+	Groups []Group
+
+	// PreserveOrder orders the methods by the source filename and
+	// their position there instead of the order of the Files.
+	PreserveOrder bool
+
+	// Rename maps the method names of the struct to
+	// the names they get in the interface.
+	Rename map[string]string
+
+	// StripMethodPrefix is removed from the method names starting with it,
+	// the Rename ones aside. Two methods named the same then fail the
+	// generation.
+	StripMethodPrefix string
+
+	// Order lists the methods of the interface, by the names they have
+	// there, which come first in that order. The rest follow sorted by
+	// name. The methods are in the source order if it is empty.
+	Order []string
+
+	// CommentWidth rewraps the prose of the docs copied to the interface
+	// at the column, the docs are kept as they are if it is zero.
+	CommentWidth int
+
+	// IndentSpaces expands the indentation tabs of the formatted
+	// code to the number of spaces, tabs are kept if it is zero.
+	IndentSpaces int
+
+	// License is a header prepended to the generated file. It is turned
+	// into line comments unless it is commented already.
+	License string
+
+	// FactorCommon is the name of an interface GenerateAll declares with
+	// the methods, of the same name and signature, every interface has.
+	// The interfaces embed it instead of listing them. Nothing is factored
+	// out of less than two interfaces.
+	FactorCommon string
+
+	// Prelude is Go code, type and const declarations, inserted after the
+	// imports. The declarations the interfaces use don't have to be in
+	// the output package then. It can't import packages, goimports adds
+	// the ones it refers to.
+	Prelude string
+
+	// Version and Invocation are written to the header,
+	// so the file can be reproduced later.
+	Version    string
+	Invocation []string
+
+	// SourceHash is a hash of the sources written to the header, so a run
+	// can tell the file is up to date without generating it, see ReadSourceHash.
+	SourceHash string
+
+	// DirectiveArgs are the arguments of the caller the options don't carry,
+	// the directory the source module is read from for one. They are written
+	// to the go:generate directive as they are, after the others.
+	DirectiveArgs []string
+
+	// ResolvePackage returns the source files of a package by its import
+	// path. It is used to promote the methods of the types embedded from
+	// the other packages, which are skipped if it is not set.
+	ResolvePackage func(importPath string) ([]string, error)
+
+	// CheckErrorLast warns about the methods returning an error
+	// not as the last result, which breaks the Go convention.
+	CheckErrorLast bool
+
+	// IncludeUnexported adds the unexported methods of the struct, which is
+	// only possible if the interface is generated into the source package,
+	// OutputImportPath must be the one of the SourcePackage.
+	IncludeUnexported bool
+
+	// WidenVariadic turns the variadic parameters into slices, which are
+	// easier to match in mocks. It changes the way the methods are called,
+	// so every widened method is warned about.
+	WidenVariadic bool
+
+	// BestEffort replaces the type expressions which are not supported
+	// with any and warns about them instead of failing the generation.
+	BestEffort bool
+
+	// PostProcess receives the generated file before it is printed, so it can
+	// be changed programmatically. The result is formatted with goimports
+	// again and the generation fails if it doesn't parse.
+	PostProcess func(fset *token.FileSet, file *ast.File) error
+
+	// Warn reports the problems which don't stop the generation,
+	// they are discarded if it is not set.
+	Warn func(message string)
+
+	// skipEmpty fails the generation with errNoMethods
+	// instead of rendering the interfaces without methods
+	skipEmpty bool
+
+	// factored are the methods GenerateAll moved to the FactorCommon interface
+	factored *factoredMethods
+
+	// noDirective leaves the go:generate directive out of the file
+	noDirective bool
+
+	// reused is the existing interface ReuseExistingInterface added to the Embeds
+	reused Embed
+
+	// cache shares the parsed packages between the generations
+	// of a run, every Generate call has its own if it is not set
+	cache *packageCache
 }
 
-func Generate(options Options) ([]byte, error) {
-	var sourcePackageName string
-	var interfaceDoc string
-	parsedDeclaredTypes := make(map[string]struct{})
+// errNoMethods is returned when no method is left to generate.
+var errNoMethods = errors.New("no methods to generate")
+
+// File is one of the files generated by GenerateAll.
+type File struct {
+	Filename string
+	Code     []byte
+}
+
+func (o Options) warnf(format string, args ...interface{}) {
+	if o.Warn != nil {
+		o.Warn(fmt.Sprintf(format, args...))
+	}
+}
+
+// GenerateTo writes the generated interface to w. Nothing is written
+// if the generation fails.
+func GenerateTo(w io.Writer, options Options) error {
+	code, err := Generate(options)
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Write(code); err != nil {
+		return fmt.Errorf("writing the result: %v", err)
+	}
+
+	return nil
+}
+
+// GenerateAll generates an interface named after every exported struct of the
+// Files, OutputFilename is the directory they are generated in, the files are
+// named after the structs in the FilenameCase unless there is an
+// OutputTemplate. The structs which have no methods left after filtering
+// are skipped. With FactorCommon the methods all the interfaces have are
+// moved to an interface of that name, generated as well, they embed.
+func GenerateAll(options Options) ([]File, error) {
+	if options.FactorCommon != "" && options.EmitStub {
+		return nil, errors.New("stubs can't be generated for the interfaces embedding the common one")
+	}
+
+	if options.cache == nil {
+		options.cache = newPackageCache()
+	}
+
+	pkg, err := options.cache.source(sourceImportPath(options), options.Files, options.SiblingFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	var structs []string
+	for _, t := range listTypes(pkg) {
+		if t.Kind != TypeKindStruct {
+			continue
+		}
+		if t.Name == options.FactorCommon {
+			return nil, fmt.Errorf("the common interface %s is named as the interface of a struct", t.Name)
+		}
+		structs = append(structs, t.Name)
+	}
 
-	for _, f := range options.Files {
-		src, err := os.ReadFile(f)
+	var factored *factoredMethods
+
+	if options.FactorCommon != "" {
+		if factored, err = factorCommon(options, pkg, structs); err != nil {
+			return nil, err
+		}
+		if factored == nil {
+			options.warnf("no methods are common to the structs, %s is not generated", options.FactorCommon)
+		}
+	}
+
+	var files []File
+
+	for _, name := range structs {
+		structOptions, err := allStructOptions(options, name, name)
 		if err != nil {
 			return nil, err
 		}
+		if factored.embeds(name) {
+			structOptions.factored = factored
+		}
+		// the directive of the common interface regenerates them
+		structOptions.noDirective = factored != nil
 
-		parsed, err := parser.ParseFile(token.NewFileSet(), "", src, parser.ParseComments)
+		code, err := Generate(structOptions)
+		if errors.Is(err, errNoMethods) {
+			options.warnf("struct %s has no methods to generate, skipping it", name)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("generating %s: %v", name, err)
+		}
+
+		files = append(files, File{Filename: structOptions.OutputFilename, Code: code})
+	}
+
+	if factored != nil {
+		// the methods and their docs are taken from the first struct
+		commonOptions, err := allStructOptions(options, factored.structs[0], options.FactorCommon)
 		if err != nil {
 			return nil, err
 		}
+		commonOptions.factored = &factoredMethods{names: factored.names, structs: factored.structs, only: true}
 
-		if sourcePackageName == "" {
-			sourcePackageName = identName(parsed.Name)
+		code, err := Generate(commonOptions)
+		if err != nil {
+			return nil, fmt.Errorf("generating %s: %v", options.FactorCommon, err)
 		}
 
-		if interfaceDoc == "" {
-			interfaceDoc = parseInterfaceDoc(parsed, options.StructName)
+		files = append(files, File{Filename: commonOptions.OutputFilename, Code: code})
+	}
+
+	return files, nil
+}
+
+// allStructOptions are the options GenerateAll generates
+// the interface of the struct with into its own file.
+func allStructOptions(options Options, structName, interfaceName string) (Options, error) {
+	options.StructName = structName
+	options.InterfaceName = interfaceName
+	filename := PackageFilename(Filename(interfaceName, options.FilenameCase), options.OutputPackageName)
+	options.OutputFilename = filepath.Join(options.OutputFilename, filename)
+	if options.OutputTemplate != nil {
+		vars := OutputVars{Struct: structName, Interface: interfaceName, Package: options.OutputPackageName}
+		var err error
+		if options.OutputFilename, err = OutputPath(options.OutputTemplate, vars); err != nil {
+			return Options{}, fmt.Errorf("making the output path of %s: %v", interfaceName, err)
 		}
+	}
+	options.skipEmpty = true
+
+	return options, nil
+}
+
+func Generate(options Options) ([]byte, error) {
+	if err := checkToolchain(options.SourceGoVersion); err != nil {
+		return nil, err
+	}
+
+	if options.IncludeUnexported && !options.intoSourcePackage() {
+		return nil, fmt.Errorf(
+			"unexported methods can only be included into the source package %s, not into %s",
+			sourceImportPath(options), options.OutputImportPath,
+		)
+	}
+
+	if err := checkPrelude(options.Prelude); err != nil {
+		return nil, err
+	}
+
+	if options.NoImports {
+		options.warnf("%s is written without the imports, it may not compile on its own", options.OutputFilename)
+	}
+
+	if options.FuncType != "" && (len(options.Roles) > 0 || len(options.Embeds) > 0 || options.ReuseExistingInterface || options.EmitStub) {
+		return nil, errors.New("a func type can't have roles, stubs or embedded interfaces")
+	}
+
+	// the stubs would have to implement the embedded methods as well
+	if options.EmitStub && (len(options.Embeds) > 0 || options.ReuseExistingInterface) {
+		return nil, errors.New("stubs can't be generated for the interfaces embedding others")
+	}
+
+	if options.cache == nil {
+		options.cache = newPackageCache()
+	}
+
+	pkg, err := options.cache.source(sourceImportPath(options), options.Files, options.SiblingFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	if options.BestEffort {
+		pkg.substituteUnsupported(options)
+	}
+
+	typeParams, err := parseTypeParams(options, pkg)
+	if err != nil {
+		return nil, err
+	}
 
-		for _, t := range parseTypesFromFile(parsed) {
-			parsedDeclaredTypes[t] = struct{}{}
+	var interfaceDoc string
+
+	if options.CopyTypeDoc {
+		for _, f := range pkg.files {
+			if interfaceDoc = parseInterfaceDoc(f, options.StructName); interfaceDoc != "" {
+				break
+			}
 		}
 	}
 
-	var receivers []Receiver
-	fileSet := token.NewFileSet()
+	methods, err := newMethodCollector(options).collect(pkg, options.StructName)
+	if err != nil {
+		return nil, err
+	}
 
-	for _, f := range options.Files {
-		src, err := os.ReadFile(f)
-		if err != nil {
+	methods = filterMethods(options, methods)
+
+	if options.AddedSince != "" {
+		if methods, err = addedMethods(options, methods); err != nil {
+			return nil, err
+		}
+	}
+
+	if options.FuncType != "" {
+		if methods, err = funcTypeMethod(options, methods); err != nil {
+			return nil, err
+		}
+	}
+
+	receivers := make([]Receiver, len(methods))
+	for i, m := range methods {
+		receivers[i] = m.Receiver
+	}
+
+	renames := directiveRenames(receivers, options.Rename)
+	if options.StripMethodPrefix != "" {
+		renames = prefixRenames(receivers, options.StripMethodPrefix, renames)
+	}
+
+	unknown, err := renameReceivers(receivers, renames)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range unknown {
+		options.warnf("method %s to rename is not found", name)
+	}
+
+	if len(options.Order) > 0 {
+		var unordered []string
+		receivers, unordered = orderReceivers(receivers, options.Order)
+		for _, name := range unordered {
+			options.warnf("method %s to order is not found", name)
+		}
+	}
+
+	embedded := make(map[string]embeddedMethod)
+
+	if len(options.Embeds) > 0 {
+		if embedded, err = newMethodCollector(options).embeddedMethods(options.Embeds); err != nil {
 			return nil, err
 		}
+	}
 
-		parsed, err := parser.ParseFile(fileSet, "", src, parser.ParseComments)
+	if options.ReuseExistingInterface {
+		existing, methods, err := newMethodCollector(options).existingInterface(pkg, receivers)
 		if err != nil {
 			return nil, err
 		}
 
-		fileReceivers := ParseReceivers(
-			parsed,
-			fileSet,
-			options.StructName,
-			sourcePackageName,
-			parsedDeclaredTypes,
-		)
-		receivers = append(receivers, fileReceivers...)
+		if len(methods) == 0 {
+			options.warnf("no interface of package %s is implemented by %s, nothing to reuse", pkg.name, options.StructName)
+		} else {
+			if err := addEmbedded(embedded, existing, methods); err != nil {
+				return nil, err
+			}
+
+			// the directive finds it again instead of embedding it with --embed
+			options.Embeds = append(options.Embeds[:len(options.Embeds):len(options.Embeds)], existing)
+			options.reused = existing
+		}
+	}
+
+	if len(options.Embeds) > 0 {
+		if err := checkEmbedded(receivers, embedded); err != nil {
+			return nil, err
+		}
+
+		if !options.EmbedNoDedup {
+			receivers = dedupEmbedded(receivers, embedded)
+		}
+	}
+
+	if options.WidenVariadic {
+		for _, r := range receivers {
+			if widenVariadic(r) {
+				options.warnf("variadic parameter of method %s is widened to a slice, the callers have to pass one", r.Name)
+			}
+		}
+	}
+
+	if options.CheckErrorLast {
+		for _, r := range receivers {
+			if !errorIsLast(r) {
+				options.warnf("method %s doesn't return the error as the last result", r.Name)
+			}
+		}
+	}
+
+	if options.FlagMissingContext {
+		flagMissingContext(receivers)
+	}
+
+	if options.CommentWidth > 0 {
+		interfaceDoc = wrapComment(interfaceDoc, options.CommentWidth)
+		for i, r := range receivers {
+			receivers[i].Comment = wrapComment(r.Comment, options.CommentWidth)
+		}
+	}
+
+	empty := true
+
+	for _, iface := range interfacesOf(options, receivers) {
+		if len(iface.receivers) == 0 && len(options.Roles) > 0 {
+			options.warnf("role %s has no methods", iface.name)
+		}
+		if len(iface.receivers) > 0 {
+			empty = false
+		}
+	}
+
+	if empty && options.skipEmpty {
+		return nil, errNoMethods
+	}
+
+	if f := options.factored; f != nil {
+		receivers = f.split(receivers)
+		if f.only {
+			interfaceDoc = f.doc(options.InterfaceName)
+		} else {
+			// declared in the result package by GenerateAll
+			options.Embeds = append(options.Embeds[:len(options.Embeds):len(options.Embeds)], Embed{Name: options.FactorCommon})
+		}
+	}
+
+	if options.EmitStub {
+		newMethodCollector(options).resolveZeros(pkg, receivers)
+	}
+
+	if options.intoSourcePackage() {
+		unqualifyImport(typeParams, receivers, sourceImportPath(options))
 	}
 
-	return RenderInterface(
-		options.OutputPackageName,
-		options.InterfaceName,
-		options.StructName,
-		options.SourcePackage,
-		options.ModulePath,
-		options.OutputFilename,
-		receivers,
-	)
+	disambiguateImports(typeParams, receivers)
+
+	for _, r := range receivers {
+		for _, renamed := range renameShadowingParams(r) {
+			options.warnf("parameter %s of method %s shadows the package it uses, renamed to %s", renamed[0], r.Name, renamed[1])
+		}
+	}
+
+	if err := checkInternalImports(options, typeParams, receivers); err != nil {
+		return nil, err
+	}
+
+	if err := checkVariadic(receivers); err != nil {
+		return nil, err
+	}
+
+	return RenderInterface(options, interfaceDoc, typeParams, receivers)
 }
 
-func parseInterfaceDoc(parsed *ast.File, structName string) string {
-	ast.Inspect(parsed, func(node ast.Node) bool {
-		n, ok := node.(*ast.TypeSpec)
-		if !ok {
-			return true
+// ownEmbed reports whether the embedded interface is declared
+// in the result package, so it is referred to without the package.
+// The FactorCommon one has no import path.
+func (o Options) ownEmbed(e Embed) bool {
+	return e.ImportPath == "" || (o.OutputImportPath != "" && e.ImportPath == o.OutputImportPath)
+}
+
+// funcTypeMethod keeps the method the FuncType is generated for.
+func funcTypeMethod(options Options, methods []method) ([]method, error) {
+	for _, m := range methods {
+		if m.Name == options.FuncType {
+			return []method{m}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("method %s of %s is not found", options.FuncType, options.StructName)
+}
+
+// filterMethods drops the methods skipped with //ifacemaker:skip, the ones
+// out of the value method set with MethodSetValue and the methods declared
+// on the struct, and the promoted ones with FilterPromoted, which don't pass
+// the Include and Exclude filters.
+func filterMethods(options Options, methods []method) []method {
+	var filtered []method
+
+	for _, m := range methods {
+		if m.skip || (m.pointer && options.MethodSet == MethodSetValue) {
+			continue
+		}
+		if m.depth == 0 || options.FilterPromoted {
+			if options.Include != nil && !options.Include.MatchString(m.Name) {
+				continue
+			}
+			if options.Exclude != nil && options.Exclude.MatchString(m.Name) {
+				continue
+			}
+		}
+
+		filtered = append(filtered, m)
+	}
+
+	return filtered
+}
+
+// addedMethods drops the methods which have the same
+// signature in the older version of the source package.
+func addedMethods(options Options, methods []method) ([]method, error) {
+	baseline, err := options.cache.source(sourceImportPath(options), options.BaselineFiles, nil)
+	if err != nil {
+		return nil, fmt.Errorf("parsing the %s version: %v", options.AddedSince, err)
+	}
+
+	if options.BestEffort {
+		baseline.substituteUnsupported(options)
+	}
+
+	existing, err := newMethodCollector(options).collect(baseline, options.StructName)
+	if err != nil {
+		return nil, fmt.Errorf("collecting the %s version methods: %v", options.AddedSince, err)
+	}
+
+	signatures := make(map[string]struct{}, len(existing))
+	for _, m := range existing {
+		signatures[m.signature()] = struct{}{}
+	}
+
+	var added []method
+
+	for _, m := range methods {
+		if _, ok := signatures[m.signature()]; !ok {
+			added = append(added, m)
 		}
+	}
+
+	return added, nil
+}
 
-		if n.Name.String() != structName {
-			return true
+// parseTypeParams returns the type parameters of a generic
+// struct, so the interface gets the same ones.
+func parseTypeParams(options Options, pkg *sourcePackage) ([]*Param, error) {
+	spec, file := pkg.lookupType(options.StructName)
+	if spec == nil || spec.TypeParams == nil {
+		return nil, nil
+	}
+
+	if !options.supportsGenerics() {
+		return nil, fmt.Errorf("%s has type parameters, which are not supported by go%s", options.StructName, strings.TrimPrefix(options.GoVersion, "go"))
+	}
+
+	typeParams, err := ParseMany(extractList(spec.TypeParams), pkg.types, pkg.name)
+	if err != nil {
+		return nil, positionError(pkg.fileSet, err)
+	}
+
+	resolveParamImportPaths(typeParams, file, pkg)
+
+	return typeParams, nil
+}
+
+// intoSourcePackage reports whether the interface
+// is generated into the package of the struct.
+func (o Options) intoSourcePackage() bool {
+	return o.OutputImportPath != "" && o.OutputImportPath == sourceImportPath(o)
+}
+
+// sourceImportPath returns an import path of the source package:
+// github.com/hashicorp/vault@v1.8.2 with module path api ->
+// github.com/hashicorp/vault/api, unless SourceImportPath is set.
+func sourceImportPath(options Options) string {
+	if options.SourceImportPath != "" {
+		return options.SourceImportPath
+	}
+	if options.SourcePackage == "" {
+		return ""
+	}
+
+	modulePath, _, _ := strings.Cut(options.SourcePackage, "@")
+
+	return path.Join(modulePath, options.ModulePath)
+}
+
+func parseInterfaceDoc(parsed *ast.File, structName string) string {
+	for _, decl := range parsed.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			continue
 		}
 
-		if n.Doc == nil {
-			return true
+		for _, spec := range genDecl.Specs {
+			n := spec.(*ast.TypeSpec)
+			if n.Name.String() != structName {
+				continue
+			}
+
+			doc := n.Doc
+
+			// a doc comment of a standalone declaration
+			// is attached to the GenDecl, not to the spec
+			if doc == nil && !genDecl.Lparen.IsValid() {
+				doc = genDecl.Doc
+			}
+
+			return parseReceiverDocs(extractComments(doc))
 		}
-		// TODO
-		return true
-	})
+	}
 
 	return ""
 }
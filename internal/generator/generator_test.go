@@ -1,9 +1,18 @@
 package generator
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/token"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -11,12 +20,69 @@ import (
 )
 
 type testCase struct {
-	Module         string   `yaml:"module"`
-	Files          []string `yaml:"files"`
-	StructName     string   `yaml:"struct_name"`
-	InterfaceName  string   `yaml:"interface_name"`
-	OutPackageName string   `yaml:"out_package_name"`
-	OutputFilename string   `yaml:"output_filename"`
+	Module                string            `yaml:"module"`
+	SourcePackage         string            `yaml:"source_package"`
+	ModulePath            string            `yaml:"module_path"`
+	SourceImportPath      string            `yaml:"source_import_path"`
+	OutputImportPath      string            `yaml:"output_import_path"`
+	Files                 []string          `yaml:"files"`
+	SiblingFiles          []string          `yaml:"sibling_files"`
+	AddedSince            string            `yaml:"added_since"`
+	BaselineFiles         []string          `yaml:"baseline_files"`
+	StructName            string            `yaml:"struct_name"`
+	InterfaceName         string            `yaml:"interface_name"`
+	OutPackageName        string            `yaml:"out_package_name"`
+	OutputFilename        string            `yaml:"output_filename"`
+	CopyTypeDoc           bool              `yaml:"copy_type_doc"`
+	GroupImports          bool              `yaml:"group_imports"`
+	NoImports             bool              `yaml:"no_imports"`
+	ReplaceUnexportedWith string            `yaml:"replace_unexported_with"`
+	UseAny                bool              `yaml:"use_any"`
+	GoVersion             string            `yaml:"go_version"`
+	SourceGoVersion       string            `yaml:"source_go_version"`
+	Rename                map[string]string `yaml:"rename"`
+	Order                 []string          `yaml:"order"`
+	StripMethodPrefix     string            `yaml:"strip_method_prefix"`
+	Roles                 []struct {
+		Name   string `yaml:"name"`
+		Filter string `yaml:"filter"`
+	} `yaml:"roles"`
+	Groups []struct {
+		Banner string `yaml:"banner"`
+		Prefix string `yaml:"prefix"`
+	} `yaml:"groups"`
+	Embeds []struct {
+		ImportPath string `yaml:"import_path"`
+		Name       string `yaml:"name"`
+	} `yaml:"embeds"`
+	EmbedNoDedup           bool     `yaml:"embed_no_dedup"`
+	ReuseExistingInterface bool     `yaml:"reuse_existing_interface"`
+	FuncType               string   `yaml:"func_type"`
+	EmitStub               bool     `yaml:"emit_stub"`
+	Include                string   `yaml:"include"`
+	Exclude                string   `yaml:"exclude"`
+	FilterPromoted         bool     `yaml:"filter_promoted"`
+	MethodSet              string   `yaml:"method_set"`
+	IncludeUnexported      bool     `yaml:"include_unexported"`
+	PreserveOrder          bool     `yaml:"preserve_order"`
+	AnnotateSource         bool     `yaml:"annotate_source"`
+	TrimPrefix             string   `yaml:"trim_prefix"`
+	CheckErrorLast         bool     `yaml:"check_error_last"`
+	WidenVariadic          bool     `yaml:"widen_variadic"`
+	BestEffort             bool     `yaml:"best_effort"`
+	FlagMissingContext     bool     `yaml:"flag_missing_context"`
+	CommentWidth           int      `yaml:"comment_width"`
+	IndentSpaces           int      `yaml:"indent_spaces"`
+	LicenseFile            string   `yaml:"license_file"`
+	PreludeFile            string   `yaml:"prelude_file"`
+	Version                string   `yaml:"version"`
+	Invocation             []string `yaml:"invocation"`
+	SourceHash             string   `yaml:"source_hash"`
+	Warnings               []string `yaml:"warnings"`
+	Error                  string   `yaml:"error"`
+
+	// import path -> directory relative to the case
+	Packages map[string]string `yaml:"packages"`
 }
 
 func TestGenerate(t *testing.T) {
@@ -39,6 +105,346 @@ func TestGenerate(t *testing.T) {
 			name:      "mattermost Audit package rename",
 			directory: "03_audit_package_rename",
 		},
+		{
+			name:      "copy type doc",
+			directory: "04_copy_type_doc",
+		},
+		{
+			name:      "embedded struct from another module",
+			directory: "05_embed_other_module",
+		},
+		{
+			name:      "version header",
+			directory: "06_header_version",
+		},
+		{
+			name:      "replace unexported types",
+			directory: "07_replace_unexported",
+		},
+		{
+			name:      "single source file",
+			directory: "09_source_file",
+		},
+		{
+			name:      "grouped imports",
+			directory: "10_grouped_imports",
+		},
+		{
+			name:      "indent with spaces",
+			directory: "11_indent_spaces",
+		},
+		{
+			name:      "cyclic embedding",
+			directory: "12_cyclic_embedding",
+		},
+		{
+			name:      "source types named as builtins",
+			directory: "13_builtin_names",
+		},
+		{
+			name:      "go version without any",
+			directory: "14_go_version_any",
+		},
+		{
+			name:      "go version without generics",
+			directory: "15_go_version_generics",
+		},
+		{
+			name:      "anonymous struct results",
+			directory: "16_struct_results",
+		},
+		{
+			name:      "source module with generics",
+			directory: "17_source_go_version",
+		},
+		{
+			name:      "rename methods",
+			directory: "18_rename",
+		},
+		{
+			name:      "rename collision",
+			directory: "19_rename_collision",
+		},
+		{
+			name:      "generic constraint unions",
+			directory: "20_generic_unions",
+		},
+		{
+			name:      "preserve order across files",
+			directory: "21_preserve_order",
+		},
+		{
+			name:      "trimmed source annotation",
+			directory: "22_trim_prefix",
+		},
+		{
+			name:      "internal source package",
+			directory: "23_internal_allowed",
+		},
+		{
+			name:      "internal source package out of scope",
+			directory: "24_internal_forbidden",
+		},
+		{
+			name:      "named interface results",
+			directory: "25_interface_results",
+		},
+		{
+			name:      "role interfaces",
+			directory: "26_roles",
+		},
+		{
+			name:      "unsupported type position",
+			directory: "27_unsupported_type",
+		},
+		{
+			name:      "flag methods missing context",
+			directory: "29_missing_context",
+		},
+		{
+			name:      "methods added since an older version",
+			directory: "30_added_since",
+		},
+		{
+			name:      "blank receiver names",
+			directory: "31_blank_receivers",
+		},
+		{
+			name:      "embedded interfaces",
+			directory: "32_embed",
+		},
+		{
+			name:      "embedded interfaces without dedup",
+			directory: "33_embed_no_dedup",
+		},
+		{
+			name:      "qualified generic type arguments",
+			directory: "35_generic_qualified",
+		},
+		{
+			name:      "unsupported type best effort",
+			directory: "36_best_effort",
+		},
+		{
+			name:      "overlapping embedded interfaces",
+			directory: "37_embed_overlap",
+		},
+		{
+			name:      "conflicting embedded interfaces",
+			directory: "38_embed_conflict",
+		},
+		{
+			name:      "method conflicting with embedded interface",
+			directory: "39_embed_signature",
+		},
+		{
+			name:      "error last convention",
+			directory: "40_error_last",
+		},
+		{
+			name:      "package name differs from directory",
+			directory: "41_package_name",
+		},
+		{
+			name:      "plain text license",
+			directory: "42_license_plain",
+		},
+		{
+			name:      "commented license",
+			directory: "43_license_commented",
+		},
+		{
+			name:      "variadic functional options",
+			directory: "44_variadic_options",
+		},
+		{
+			name:      "same-named imports",
+			directory: "45_duplicate_import_names",
+		},
+		{
+			name:      "methods declared on aliases",
+			directory: "46_receiver_alias",
+		},
+		{
+			name:      "filter promoted methods",
+			directory: "47_filter_promoted",
+		},
+		{
+			name:      "packages referenced by results only",
+			directory: "48_result_only_import",
+		},
+		{
+			name:      "widen variadic parameters",
+			directory: "49_widen_variadic",
+		},
+		{
+			name:      "docs of multi-line signatures",
+			directory: "50_multiline_signature",
+		},
+		{
+			name:      "major version import paths",
+			directory: "51_major_version_import",
+		},
+		{
+			name:      "external test package",
+			directory: "52_test_package",
+		},
+		{
+			name:      "source hash",
+			directory: "53_source_hash",
+		},
+		{
+			name:      "unexported methods in the source package",
+			directory: "54_include_unexported",
+		},
+		{
+			name:      "unexported methods in another package",
+			directory: "55_include_unexported_other_package",
+		},
+		{
+			name:      "reuse existing interface",
+			directory: "56_reuse_existing_interface",
+		},
+		{
+			name:      "group by prefix",
+			directory: "57_group_by_prefix",
+		},
+		{
+			name:      "func type",
+			directory: "58_func_type",
+		},
+		{
+			name:      "func type of an unknown method",
+			directory: "59_func_type_unknown_method",
+		},
+		{
+			name:      "two major versions of a module",
+			directory: "60_two_major_versions",
+		},
+		{
+			name:      "promoted methods shadowed by fields",
+			directory: "61_field_shadows_method",
+		},
+		{
+			name:      "stub with the zero values",
+			directory: "62_emit_stub",
+		},
+		{
+			name:      "stub of a generic interface",
+			directory: "63_emit_stub_generic",
+		},
+		{
+			name:      "params named as the packages",
+			directory: "64_param_shadows_package",
+		},
+		{
+			name:      "prelude declaring a type alias",
+			directory: "65_prelude",
+		},
+		{
+			name:      "prelude importing a package",
+			directory: "66_prelude_import",
+		},
+		{
+			name:      "types qualified with their own package",
+			directory: "67_self_qualified",
+		},
+		{
+			name:      "types qualified with their own package into it",
+			directory: "68_self_qualified_same_package",
+		},
+		{
+			name:      "skip and rename directives",
+			directory: "69_source_directives",
+		},
+		{
+			name:      "invalid directive",
+			directory: "70_invalid_directive",
+		},
+		{
+			name:      "ordered methods first",
+			directory: "71_order",
+		},
+		{
+			name:      "file referencing the struct only",
+			directory: "72_reference_only_file",
+		},
+		{
+			name:      "docs wrapped at a width",
+			directory: "73_comment_width",
+		},
+		{
+			name:      "embedded fmt.Stringer",
+			directory: "74_embed_stringer",
+		},
+		{
+			name:      "struct of a grouped declaration",
+			directory: "75_grouped_type",
+		},
+		{
+			name:      "source package types imported once",
+			directory: "76_source_types",
+		},
+		{
+			name:      "source import path apart from the fetch one",
+			directory: "77_source_import_path",
+		},
+		{
+			name:      "method type parameters",
+			directory: "78_method_type_params",
+		},
+		{
+			name:      "builtin shadowed by the package",
+			directory: "80_shadowed_builtin",
+		},
+		{
+			name:      "defined type over a struct",
+			directory: "81_defined_struct_type",
+		},
+		{
+			name:      "method prefix stripped",
+			directory: "82_strip_method_prefix",
+		},
+		{
+			name:      "method prefix stripped into a clash",
+			directory: "83_strip_method_prefix_clash",
+		},
+		{
+			name:      "packages of the constraints only",
+			directory: "84_external_constraint",
+		},
+		{
+			name:      "no imports",
+			directory: "85_no_imports",
+		},
+		{
+			name:      "main package",
+			directory: "87_main_package",
+		},
+		{
+			name:      "rename doc",
+			directory: "88_rename_doc",
+		},
+		{
+			name:      "misplaced ellipsis",
+			directory: "89_misplaced_ellipsis",
+		},
+		{
+			name:      "value method set",
+			directory: "90_method_set_value",
+		},
+		{
+			name:      "pointer method set",
+			directory: "91_method_set_pointer",
+		},
+		{
+			name:      "generic embed with qualified type arguments",
+			directory: "92_generic_embed_qualified",
+		},
+		{
+			name:      "fixed size arrays",
+			directory: "93_fixed_size_arrays",
+		},
 	}
 
 	for _, tc := range cases {
@@ -50,25 +456,450 @@ func TestGenerate(t *testing.T) {
 			spec := testReadFile(t, tc.directory, "case.yml")
 			var test testCase
 			testUnmarshalYaml(t, spec, &test)
-			testGetPackage(t, test.Module, modcache)
-			want := testReadFileString(t, tc.directory, "out.txt")
+
+			// local cases keep their sources next to case.yml
+			files := encodeFiles(test.Files, filepath.Join("testdata", tc.directory))
+			if test.Module != "" {
+				testGetPackage(t, test.Module, modcache)
+				files = encodeFiles(test.Files, modcache)
+			}
+
+			var groups []Group
+			for _, g := range test.Groups {
+				groups = append(groups, Group{Banner: g.Banner, Prefix: g.Prefix})
+			}
+
+			var roles []Role
+			for _, r := range test.Roles {
+				roles = append(roles, Role{Name: r.Name, Filter: regexp.MustCompile(r.Filter)})
+			}
+
+			var embeds []Embed
+			for _, e := range test.Embeds {
+				embeds = append(embeds, Embed{ImportPath: e.ImportPath, Name: e.Name})
+			}
+
+			var include, exclude *regexp.Regexp
+			if test.Include != "" {
+				include = regexp.MustCompile(test.Include)
+			}
+			if test.Exclude != "" {
+				exclude = regexp.MustCompile(test.Exclude)
+			}
+
+			var license string
+			if test.LicenseFile != "" {
+				license = testReadFileString(t, tc.directory, test.LicenseFile)
+			}
+
+			var prelude string
+			if test.PreludeFile != "" {
+				prelude = testReadFileString(t, tc.directory, test.PreludeFile)
+			}
+
+			var warnings []string
 
 			// act
 			got, err := Generate(Options{
-				Files:             encodeFiles(test.Files, modcache),
-				StructName:        test.StructName,
-				InterfaceName:     test.InterfaceName,
-				OutputPackageName: test.OutPackageName,
-				OutputFilename:    test.OutputFilename,
+				Files:                  files,
+				SiblingFiles:           encodeFiles(test.SiblingFiles, filepath.Join("testdata", tc.directory)),
+				AddedSince:             test.AddedSince,
+				BaselineFiles:          encodeFiles(test.BaselineFiles, filepath.Join("testdata", tc.directory)),
+				StructName:             test.StructName,
+				InterfaceName:          test.InterfaceName,
+				OutputPackageName:      test.OutPackageName,
+				OutputFilename:         test.OutputFilename,
+				CopyTypeDoc:            test.CopyTypeDoc,
+				GroupImports:           test.GroupImports,
+				NoImports:              test.NoImports,
+				ReplaceUnexportedWith:  test.ReplaceUnexportedWith,
+				UseAny:                 test.UseAny,
+				GoVersion:              test.GoVersion,
+				SourceGoVersion:        test.SourceGoVersion,
+				Rename:                 test.Rename,
+				Order:                  test.Order,
+				StripMethodPrefix:      test.StripMethodPrefix,
+				Roles:                  roles,
+				Groups:                 groups,
+				Embeds:                 embeds,
+				EmbedNoDedup:           test.EmbedNoDedup,
+				ReuseExistingInterface: test.ReuseExistingInterface,
+				FuncType:               test.FuncType,
+				EmitStub:               test.EmitStub,
+				Include:                include,
+				Exclude:                exclude,
+				FilterPromoted:         test.FilterPromoted,
+				MethodSet:              test.MethodSet,
+				IncludeUnexported:      test.IncludeUnexported,
+				PreserveOrder:          test.PreserveOrder,
+				AnnotateSource:         test.AnnotateSource,
+				TrimPrefix:             test.TrimPrefix,
+				SourcePackage:          test.SourcePackage,
+				ModulePath:             test.ModulePath,
+				SourceImportPath:       test.SourceImportPath,
+				OutputImportPath:       test.OutputImportPath,
+				CommentWidth:           test.CommentWidth,
+				IndentSpaces:           test.IndentSpaces,
+				FlagMissingContext:     test.FlagMissingContext,
+				BestEffort:             test.BestEffort,
+				CheckErrorLast:         test.CheckErrorLast,
+				WidenVariadic:          test.WidenVariadic,
+				License:                license,
+				Prelude:                prelude,
+				Version:                test.Version,
+				Invocation:             test.Invocation,
+				SourceHash:             test.SourceHash,
+				ResolvePackage:         testResolvePackage(tc.directory, test.Packages),
+				Warn: func(message string) {
+					warnings = append(warnings, message)
+				},
 			})
 
 			// assert
+			if test.Error != "" {
+				require.EqualError(t, err, test.Error)
+				return
+			}
+
+			want := testReadFileString(t, tc.directory, "out.txt")
 			require.NoError(t, err)
 			require.Equal(t, want, string(got))
+			require.Equal(t, test.Warnings, warnings)
 		})
 	}
 }
 
+func TestGenerateTo(t *testing.T) {
+	options := Options{
+		Files:             []string{"testdata/11_indent_spaces/client.go"},
+		StructName:        "Client",
+		InterfaceName:     "Client",
+		OutputPackageName: "client",
+		OutputFilename:    "client.go",
+		IndentSpaces:      4,
+	}
+
+	t.Run("buffer", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		// act
+		err := GenerateTo(&buf, options)
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, testReadFileString(t, "11_indent_spaces", "out.txt"), buf.String())
+	})
+
+	t.Run("failing writer", func(t *testing.T) {
+		// act
+		err := GenerateTo(failingWriter{}, options)
+
+		// assert
+		require.EqualError(t, err, "writing the result: disk is full")
+	})
+}
+
+func TestGenerateAll(t *testing.T) {
+	var warnings []string
+
+	// act
+	got, err := GenerateAll(Options{
+		Files:             []string{"testdata/28_all_structs/service.go"},
+		OutputPackageName: "service",
+		OutputFilename:    "mocks",
+		Exclude:           regexp.MustCompile(`^Ping$`),
+		Warn: func(message string) {
+			warnings = append(warnings, message)
+		},
+	})
+
+	// assert
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	require.Equal(t, filepath.Join("mocks", "client.go"), got[0].Filename)
+	require.Equal(t, testReadFileString(t, "28_all_structs", "out_client.txt"), string(got[0].Code))
+	require.Equal(t, filepath.Join("mocks", "store.go"), got[1].Filename)
+	require.Equal(t, testReadFileString(t, "28_all_structs", "out_store.txt"), string(got[1].Code))
+	require.Equal(t, []string{"struct Health has no methods to generate, skipping it"}, warnings)
+}
+
+func TestGenerateAllOutputTemplate(t *testing.T) {
+	tmpl, err := ParseOutputTemplate("gen/{{.Package}}/{{.Interface | snake}}_{{.Struct | lower}}.go")
+	require.NoError(t, err)
+
+	// act
+	got, err := GenerateAll(Options{
+		Files:             []string{"testdata/28_all_structs/service.go"},
+		OutputPackageName: "service",
+		OutputFilename:    "mocks",
+		OutputTemplate:    tmpl,
+		Exclude:           regexp.MustCompile(`^Ping$`),
+	})
+
+	// assert
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	require.Equal(t, "gen/service/client_client.go", got[0].Filename)
+	require.Equal(t, "gen/service/store_store.go", got[1].Filename)
+	// go generate runs the directive in gen/service
+	require.Contains(t, string(got[0].Code), "--output client_client.go\n")
+}
+
+func TestGenerateAllFactorCommon(t *testing.T) {
+	options := Options{
+		Files:             []string{"testdata/79_factor_common/service.go"},
+		OutputPackageName: "service",
+		OutputFilename:    "mocks",
+		FactorCommon:      "Service",
+	}
+
+	t.Run("common methods", func(t *testing.T) {
+		// act
+		got, err := GenerateAll(options)
+
+		// assert
+		require.NoError(t, err)
+		require.Len(t, got, 4)
+
+		for i, name := range []string{"cache", "client", "store", "service"} {
+			require.Equal(t, filepath.Join("mocks", name+".go"), got[i].Filename)
+			require.Equal(t, testReadFileString(t, "79_factor_common", "out_"+name+".txt"), string(got[i].Code))
+		}
+	})
+
+	t.Run("output template", func(t *testing.T) {
+		tmpl, err := ParseOutputTemplate("gen/{{.Package}}/{{.Interface | snake}}.go")
+		require.NoError(t, err)
+
+		options := options
+		options.OutputTemplate = tmpl
+
+		// act
+		got, err := GenerateAll(options)
+
+		// assert
+		require.NoError(t, err)
+		require.Len(t, got, 4)
+		require.Equal(t, "gen/service/service.go", got[3].Filename)
+		// go generate runs the directive in gen/service
+		require.Contains(t, string(got[3].Code), `--output "../../gen/{{.Package}}/{{.Interface | snake}}.go"`+"\n")
+		for _, f := range got[:3] {
+			require.NotContains(t, string(f.Code), "//go:generate")
+		}
+	})
+
+	t.Run("nothing common", func(t *testing.T) {
+		var warnings []string
+
+		options := options
+		options.Exclude = regexp.MustCompile(`^(Ping|Close)$`)
+		options.Warn = func(message string) {
+			warnings = append(warnings, message)
+		}
+
+		// act
+		got, err := GenerateAll(options)
+
+		// assert
+		require.NoError(t, err)
+		require.Len(t, got, 3)
+		require.NotContains(t, string(got[0].Code), "Service")
+		require.Equal(t, []string{"no methods are common to the structs, Service is not generated"}, warnings)
+	})
+
+	t.Run("stubs", func(t *testing.T) {
+		options := options
+		options.EmitStub = true
+
+		// act
+		_, err := GenerateAll(options)
+
+		// assert
+		require.EqualError(t, err, "stubs can't be generated for the interfaces embedding the common one")
+	})
+
+	t.Run("named as a struct", func(t *testing.T) {
+		options := options
+		options.FactorCommon = "Store"
+
+		// act
+		_, err := GenerateAll(options)
+
+		// assert
+		require.EqualError(t, err, "the common interface Store is named as the interface of a struct")
+	})
+}
+
+func TestImportsIndependentOfMethodOrder(t *testing.T) {
+	options := Options{
+		Files:             []string{"testdata/86_import_order/client.go"},
+		StructName:        "Client",
+		InterfaceName:     "Client",
+		OutputPackageName: "mocks",
+		OutputFilename:    "client.go",
+		GroupImports:      true,
+	}
+
+	importBlock := func(t *testing.T, order ...string) string {
+		t.Helper()
+
+		options := options
+		options.Order = order
+
+		code, err := Generate(options)
+		require.NoError(t, err)
+
+		block, _, ok := strings.Cut(string(code), "//go:generate")
+		require.True(t, ok)
+		return block
+	}
+
+	// act
+	want := importBlock(t, "Fetch", "Cache", "Timeout")
+	got := importBlock(t, "Timeout", "Cache", "Fetch")
+
+	// assert
+	require.Equal(t, want, got)
+	require.Contains(t, want, `import (
+	"context"
+	"time"
+
+	apiv1 "github.com/acme/api/v1"
+	"github.com/acme/cache"
+	typesv1 "github.com/acme/types/v1"
+)`)
+}
+
+func TestGenerateAllReadsFilesOnce(t *testing.T) {
+	reads := make(map[string]int)
+
+	cache := newPackageCache()
+	cache.readFile = func(filename string) ([]byte, error) {
+		reads[filename]++
+		return os.ReadFile(filename)
+	}
+
+	// act
+	got, err := GenerateAll(Options{
+		Files:             []string{"testdata/28_all_structs/service.go"},
+		OutputPackageName: "service",
+		OutputFilename:    "mocks",
+		cache:             cache,
+	})
+
+	// assert
+	require.NoError(t, err)
+	require.Len(t, got, 3)
+	require.Equal(t, map[string]int{"testdata/28_all_structs/service.go": 1}, reads)
+}
+
+func TestPostProcess(t *testing.T) {
+	options := Options{
+		Files:             []string{"testdata/34_post_process/client.go"},
+		StructName:        "Client",
+		InterfaceName:     "Client",
+		OutputPackageName: "client",
+		OutputFilename:    "client.go",
+	}
+
+	t.Run("comment every method", func(t *testing.T) {
+		options := options
+		options.PostProcess = func(fset *token.FileSet, file *ast.File) error {
+			ast.Inspect(file, func(node ast.Node) bool {
+				iface, ok := node.(*ast.InterfaceType)
+				if !ok {
+					return true
+				}
+
+				for _, method := range iface.Methods.List {
+					file.Comments = append(file.Comments, &ast.CommentGroup{List: []*ast.Comment{{
+						Slash: method.End(),
+						Text:  "// " + method.Names[0].Name + " is decorated.",
+					}}})
+				}
+
+				return false
+			})
+
+			sort.Slice(file.Comments, func(i, j int) bool {
+				return file.Comments[i].Pos() < file.Comments[j].Pos()
+			})
+
+			return nil
+		}
+
+		// act
+		got, err := Generate(options)
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, testReadFileString(t, "34_post_process", "out.txt"), string(got))
+	})
+
+	t.Run("invalid result", func(t *testing.T) {
+		options := options
+		options.PostProcess = func(fset *token.FileSet, file *ast.File) error {
+			file.Name.Name = "1client"
+			return nil
+		}
+
+		// act
+		_, err := Generate(options)
+
+		// assert
+		require.ErrorContains(t, err, "the post-processed code is invalid")
+	})
+
+	t.Run("failing hook", func(t *testing.T) {
+		options := options
+		options.PostProcess = func(fset *token.FileSet, file *ast.File) error {
+			return errors.New("no way")
+		}
+
+		// act
+		_, err := Generate(options)
+
+		// assert
+		require.EqualError(t, err, "post-processing: no way")
+	})
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk is full")
+}
+
+func TestCheckToolchain(t *testing.T) {
+	toolchainVersion = func() string { return "go1.21.5" }
+	defer func() { toolchainVersion = runtime.Version }()
+
+	cases := []struct {
+		sourceVersion string
+		wantErr       string
+	}{
+		{sourceVersion: ""},
+		{sourceVersion: "1.18"},
+		{sourceVersion: "1.21.5"},
+		{
+			sourceVersion: "1.22",
+			wantErr:       "the source module requires go1.22, but ifacemaker is built with go1.21.5, rebuild it with a newer toolchain",
+		},
+	}
+
+	for _, tc := range cases {
+		// act
+		err := checkToolchain(tc.sourceVersion)
+
+		// assert
+		if tc.wantErr != "" {
+			require.EqualError(t, err, tc.wantErr)
+			continue
+		}
+		require.NoError(t, err)
+	}
+}
+
 func encodeFiles(files []string, modpath string) []string {
 	result := make([]string, len(files))
 	for i, f := range files {
@@ -77,6 +908,16 @@ func encodeFiles(files []string, modpath string) []string {
 	return result
 }
 
+func testResolvePackage(directory string, packages map[string]string) func(string) ([]string, error) {
+	return func(importPath string) ([]string, error) {
+		dir, ok := packages[importPath]
+		if !ok {
+			return nil, fmt.Errorf("unknown package %s", importPath)
+		}
+		return filepath.Glob(filepath.Join("testdata", directory, dir, "*.go"))
+	}
+}
+
 func testReadFile(t *testing.T, directory, file string) []byte {
 	t.Helper()
 	content, err := os.ReadFile(filepath.Join("testdata", directory, file))
@@ -102,3 +943,34 @@ func testUnmarshalYaml(t *testing.T, in []byte, out interface{}) {
 	err := yaml.Unmarshal(in, out)
 	require.NoError(t, err)
 }
+
+func TestListTypes(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "08_list", "*.go"))
+	require.NoError(t, err)
+
+	// act
+	got, err := ListTypes(files)
+
+	// assert
+	require.NoError(t, err)
+	require.Equal(t, []TypeInfo{
+		{Name: "Client", Kind: TypeKindStruct, Methods: 2},
+		{Name: "Doer", Kind: TypeKindInterface},
+		{Name: "Option", Kind: TypeKindFunc},
+		{Name: "Response", Kind: TypeKindStruct, Methods: 1},
+	}, got)
+}
+
+func TestListDefinedTypes(t *testing.T) {
+	// act
+	got, err := ListTypes([]string{filepath.Join("testdata", "81_defined_struct_type", "client.go")})
+
+	// assert
+	require.NoError(t, err)
+	require.Equal(t, []TypeInfo{
+		{Name: "AdminClient", Kind: TypeKindStruct, Methods: 1},
+		{Name: "Client", Kind: TypeKindStruct, Methods: 1},
+		{Name: "Doer", Kind: TypeKindStruct, Methods: 1},
+		{Name: "Item", Kind: TypeKindStruct},
+	}, got)
+}
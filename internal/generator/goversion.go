@@ -0,0 +1,65 @@
+package generator
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+
+	"golang.org/x/mod/semver"
+)
+
+// genericsVersion introduced the type parameters along with any.
+const genericsVersion = "v1.18"
+
+// ValidGoVersion reports whether the version looks like 1.17 or go1.17.
+func ValidGoVersion(version string) bool {
+	return semver.IsValid(goSemver(version))
+}
+
+// supportsGenerics reports whether the target Go version has the type
+// parameters and any, the latest version is targeted if none is set.
+func (o Options) supportsGenerics() bool {
+	if o.GoVersion == "" {
+		return true
+	}
+	return semver.Compare(goSemver(o.GoVersion), genericsVersion) >= 0
+}
+
+// toolchainVersion is the Go version the parser comes from, mocked in tests.
+var toolchainVersion = runtime.Version
+
+// checkToolchain fails if the source module declares a Go version newer than
+// the toolchain, whose parser doesn't know the syntax it may use. Development
+// toolchains are not checked.
+func checkToolchain(sourceVersion string) error {
+	toolchain := toolchainVersion()
+	if sourceVersion == "" || !semver.IsValid(goSemver(toolchain)) {
+		return nil
+	}
+
+	if semver.Compare(goSemver(sourceVersion), goSemver(toolchain)) > 0 {
+		return fmt.Errorf("the source module requires go%s, but ifacemaker is built with %s, rebuild it with a newer toolchain", sourceVersion, toolchain)
+	}
+
+	return nil
+}
+
+func goSemver(version string) string {
+	return "v" + strings.TrimPrefix(version, "go")
+}
+
+// useInterface spells any in the receiver's signature as interface{}.
+func useInterface(r Receiver) {
+	visit := func(t *Type) {
+		if t.Kind == TypeKindIdent && t.Package == "" && t.Name == "any" {
+			*t = Type{Kind: TypeKindInterface}
+		}
+	}
+
+	for _, p := range r.Params {
+		p.Type.walk(visit)
+	}
+	for _, p := range r.Results {
+		p.Type.walk(visit)
+	}
+}
@@ -0,0 +1,383 @@
+package generator
+
+import (
+	"go/ast"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type importSpec struct {
+	Name string
+	Path string
+}
+
+func (s importSpec) String() string {
+	if s.Name == guessPackageName(s.Path) {
+		return strconv.Quote(s.Path)
+	}
+	return s.Name + " " + strconv.Quote(s.Path)
+}
+
+// fileImports maps the package names used in the file to their import paths.
+func fileImports(file *ast.File) map[string]string {
+	imports := make(map[string]string, len(file.Imports))
+
+	for _, spec := range file.Imports {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+
+		name := guessPackageName(importPath)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+
+		if name == "_" || name == "." {
+			continue
+		}
+
+		imports[name] = importPath
+	}
+
+	return imports
+}
+
+// resolveImportPaths sets import paths of the qualified types in the methods
+// declared in the file. The types of the package itself get its own path.
+func resolveImportPaths(methods []method, file *ast.File, pkg *sourcePackage) {
+	for _, m := range methods {
+		resolveParamImportPaths(m.Params, file, pkg)
+		resolveParamImportPaths(m.Results, file, pkg)
+	}
+}
+
+func resolveParamImportPaths(params []*Param, file *ast.File, pkg *sourcePackage) {
+	imports := fileImports(file)
+
+	visit := func(t *Type) {
+		if t.Package == "" || t.ImportPath != "" {
+			return
+		}
+
+		switch {
+		case t.Kind == TypeKindIdent && t.Package == pkg.name:
+			t.ImportPath = pkg.importPath
+		case t.Kind == TypeKindSelector:
+			importPath, imported := imports[t.Package]
+			if !imported && t.Package == pkg.name {
+				// api.Request in package api, the package can't
+				// import itself, so it's a type of its own
+				t.Kind = TypeKindIdent
+				importPath = pkg.importPath
+			}
+			t.ImportPath = importPath
+		}
+	}
+
+	for _, p := range params {
+		p.Type.walk(visit)
+	}
+}
+
+// collectImports returns the packages referenced by the type parameters, the
+// receivers and the embedded interfaces sorted by path. Those which path is
+// unknown are left for goimports to find.
+func collectImports(typeParams []*Param, receivers []Receiver, embeds []Embed) []importSpec {
+	seen := make(map[string]struct{})
+	var imports []importSpec
+
+	visit := func(t *Type) {
+		if t.ImportPath == "" {
+			return
+		}
+		if _, ok := seen[t.ImportPath]; ok {
+			return
+		}
+
+		seen[t.ImportPath] = struct{}{}
+		imports = append(imports, importSpec{Name: t.Package, Path: t.ImportPath})
+	}
+
+	walkImportTypes(typeParams, receivers, visit)
+
+	for _, e := range embeds {
+		visit(&Type{Package: guessPackageName(e.ImportPath), ImportPath: e.ImportPath})
+	}
+
+	sort.Slice(imports, func(i, j int) bool {
+		return imports[i].Path < imports[j].Path
+	})
+
+	return imports
+}
+
+// unqualifyImport drops the package of the types imported from the path,
+// the interface generated into that package refers to them as is.
+func unqualifyImport(typeParams []*Param, receivers []Receiver, importPath string) {
+	walkImportTypes(typeParams, receivers, func(t *Type) {
+		if t.ImportPath != importPath {
+			return
+		}
+
+		t.Package = ""
+		t.ImportPath = ""
+		if t.Kind == TypeKindSelector {
+			t.Kind = TypeKindIdent
+		}
+	})
+}
+
+// disambiguateImports gives every imported package a single name, the files
+// may import it under different ones, and renames the packages which share
+// a name, so they don't clash in the result: github.com/acme/api/v1 and
+// github.com/acme/types/v1 both named v1 become apiv1 and typesv1. The
+// aliases are derived from the paths, so they are the same every run.
+func disambiguateImports(typeParams []*Param, receivers []Receiver) {
+	// import path -> package names
+	names := make(map[string]map[string]struct{})
+
+	walkImportTypes(typeParams, receivers, func(t *Type) {
+		if t.Package == "" || t.ImportPath == "" {
+			return
+		}
+		if names[t.ImportPath] == nil {
+			names[t.ImportPath] = make(map[string]struct{})
+		}
+		names[t.ImportPath][t.Package] = struct{}{}
+	})
+
+	// package name -> import paths
+	paths := make(map[string]map[string]struct{})
+	aliases := make(map[string]string, len(names))
+
+	for p, set := range names {
+		name := packageName(p, set)
+		aliases[p] = name
+
+		if paths[name] == nil {
+			paths[name] = make(map[string]struct{})
+		}
+		paths[name][p] = struct{}{}
+	}
+
+	clashes := make([]string, 0, len(paths))
+	for name, set := range paths {
+		if len(set) > 1 {
+			clashes = append(clashes, name)
+		}
+	}
+	sort.Strings(clashes)
+
+	for _, name := range clashes {
+		set := paths[name]
+
+		clashing := make([]string, 0, len(set))
+		for p := range set {
+			clashing = append(clashing, p)
+		}
+		sort.Strings(clashing)
+
+		renamed := pathAliases(clashing, paths)
+		delete(paths, name)
+
+		// so the other clashing packages don't get the same aliases
+		for p, alias := range renamed {
+			aliases[p] = alias
+			paths[alias] = map[string]struct{}{p: {}}
+		}
+	}
+
+	walkImportTypes(typeParams, receivers, func(t *Type) {
+		if alias, ok := aliases[t.ImportPath]; ok && t.Package != "" {
+			t.Package = alias
+		}
+	})
+}
+
+// packageName picks one of the names the package is imported under,
+// the conventional one if it is used, the first in order otherwise.
+func packageName(importPath string, names map[string]struct{}) string {
+	if _, ok := names[guessPackageName(importPath)]; ok {
+		return guessPackageName(importPath)
+	}
+
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	return sorted[0]
+}
+
+// renameShadowingParams renames the parameters and the results named as
+// a package the signature refers to, time in Do(time int) time.Duration,
+// so the package is still reachable in the bodies of the implementations.
+// The renamed ones are returned as old and new name pairs.
+func renameShadowingParams(r Receiver) [][2]string {
+	params := append(r.Params[:len(r.Params):len(r.Params)], r.Results...)
+
+	used := make(map[string]struct{})
+	for _, p := range params {
+		used[p.Name] = struct{}{}
+	}
+
+	packages := make(map[string]struct{})
+	for _, p := range params {
+		p.Type.walk(func(t *Type) {
+			if t.Package != "" {
+				packages[t.Package] = struct{}{}
+				used[t.Package] = struct{}{}
+			}
+		})
+	}
+
+	var renamed [][2]string
+
+	for _, p := range params {
+		if _, ok := packages[p.Name]; !ok {
+			continue
+		}
+
+		name := p.Name
+		for i := 1; ; i++ {
+			name = p.Name + strconv.Itoa(i)
+			if _, ok := used[name]; !ok {
+				break
+			}
+		}
+
+		used[name] = struct{}{}
+		renamed = append(renamed, [2]string{p.Name, name})
+		p.Name = name
+	}
+
+	return renamed
+}
+
+// pathAliases derives the aliases from the trailing elements of the paths,
+// taking more of them until the aliases differ from each other and the
+// names already used. The paths exhausted that way get a number appended.
+func pathAliases(importPaths []string, used map[string]map[string]struct{}) map[string]string {
+	unique := func(aliases map[string]string) bool {
+		seen := make(map[string]struct{}, len(aliases))
+		for _, alias := range aliases {
+			_, taken := used[alias]
+			if _, ok := seen[alias]; ok || taken {
+				return false
+			}
+			seen[alias] = struct{}{}
+		}
+		return true
+	}
+
+	var aliases map[string]string
+
+	for depth := 2; ; depth++ {
+		aliases = make(map[string]string, len(importPaths))
+		exhausted := true
+
+		for _, p := range importPaths {
+			elems := strings.Split(p, "/")
+			if depth < len(elems) {
+				exhausted = false
+				elems = elems[len(elems)-depth:]
+			}
+			aliases[p] = pathAlias(elems)
+		}
+
+		if unique(aliases) {
+			return aliases
+		}
+		if exhausted {
+			break
+		}
+	}
+
+	for i, p := range importPaths {
+		aliases[p] += strconv.Itoa(i + 1)
+	}
+
+	return aliases
+}
+
+// pathAlias joins the path elements keeping the letters and digits only:
+// api/v1 -> apiv1.
+func pathAlias(elems []string) string {
+	var b strings.Builder
+
+	for _, r := range strings.ToLower(strings.Join(elems, "")) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
+
+func walkImportTypes(typeParams []*Param, receivers []Receiver, visit func(t *Type)) {
+	for _, p := range typeParams {
+		p.Type.walk(visit)
+	}
+
+	for _, r := range receivers {
+		for _, p := range r.Params {
+			p.Type.walk(visit)
+		}
+		for _, p := range r.Results {
+			p.Type.walk(visit)
+		}
+	}
+}
+
+// renderImports writes an import block, grouped with the standard library
+// packages first and the third-party ones after a blank line.
+func renderImports(b *strings.Builder, imports []importSpec, grouped bool) {
+	if len(imports) == 0 {
+		return
+	}
+
+	if len(imports) == 1 && !grouped {
+		b.WriteString("import ")
+		b.WriteString(imports[0].String())
+		b.WriteString("\n")
+		return
+	}
+
+	var std, thirdParty []importSpec
+
+	for _, spec := range imports {
+		if grouped && isStandardPackage(spec.Path) {
+			std = append(std, spec)
+		} else {
+			thirdParty = append(thirdParty, spec)
+		}
+	}
+
+	b.WriteString("import (\n")
+
+	for _, spec := range std {
+		b.WriteString(spec.String())
+		b.WriteString("\n")
+	}
+
+	if len(std) > 0 && len(thirdParty) > 0 {
+		b.WriteString("\n")
+	}
+
+	for _, spec := range thirdParty {
+		b.WriteString(spec.String())
+		b.WriteString("\n")
+	}
+
+	b.WriteString(")\n")
+}
+
+// isStandardPackage reports whether the path belongs to the standard
+// library, which paths never contain a dot in the first element.
+func isStandardPackage(importPath string) bool {
+	return !strings.Contains(strings.Split(importPath, "/")[0], ".")
+}
@@ -0,0 +1,45 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// checkInternalImports fails if the result package can't import some of the
+// packages the interface refers to because of the internal visibility rule.
+func checkInternalImports(options Options, typeParams []*Param, receivers []Receiver) error {
+	if options.OutputImportPath == "" {
+		return nil
+	}
+
+	for _, spec := range collectImports(typeParams, receivers, options.Embeds) {
+		root, ok := internalRoot(spec.Path)
+		if !ok || isStandardPackage(spec.Path) {
+			continue
+		}
+
+		if options.OutputImportPath != root && !strings.HasPrefix(options.OutputImportPath, root+"/") {
+			return fmt.Errorf(
+				"%s can't import %s, internal packages are only importable from within %s, "+
+					"generate the interface there or use a different struct",
+				options.OutputImportPath, spec.Path, root,
+			)
+		}
+	}
+
+	return nil
+}
+
+// internalRoot returns the root of the tree which is allowed to import the
+// path, the parent of its last internal element.
+func internalRoot(importPath string) (string, bool) {
+	elems := strings.Split(importPath, "/")
+
+	for i := len(elems) - 1; i >= 0; i-- {
+		if elems[i] == "internal" {
+			return strings.Join(elems[:i], "/"), true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,118 @@
+package generator
+
+import (
+	"go/ast"
+	"go/token"
+	"sort"
+)
+
+// TypeInfo describes a type declared in the source package.
+type TypeInfo struct {
+	Name string
+	Kind string
+
+	// Methods is a number of the exported methods declared on the type
+	Methods int
+}
+
+// ListTypes returns the exported types declared in the files sorted by name.
+func ListTypes(files []string) ([]TypeInfo, error) {
+	pkg, err := parsePackage("", files)
+	if err != nil {
+		return nil, err
+	}
+
+	return listTypes(pkg), nil
+}
+
+// listTypes describes the exported types of the package files sorted by name.
+func listTypes(pkg *sourcePackage) []TypeInfo {
+	infos := make(map[string]*TypeInfo)
+
+	for _, f := range pkg.files {
+		for _, info := range parseTypeInfosFromFile(f) {
+			info := info
+
+			// type AdminClient Client is a struct as well
+			if spec, file := pkg.lookupType(info.Name); info.Kind == TypeKindIdent && !spec.Assign.IsValid() {
+				underlying, _ := pkg.underlyingType(spec, file)
+				info.Kind = declaredTypeKind(underlying.Type)
+			}
+
+			infos[info.Name] = &info
+		}
+	}
+
+	for _, f := range pkg.files {
+		for _, decl := range f.Decls {
+			funcDecl, ok := decl.(*ast.FuncDecl)
+			if !ok || !isReceiver(funcDecl) || !isFuncExported(funcDecl) {
+				continue
+			}
+
+			if info, ok := infos[pkg.resolveAlias(receiverTypeName(pkg.fileSet, funcDecl))]; ok {
+				info.Methods++
+			}
+		}
+	}
+
+	result := make([]TypeInfo, 0, len(infos))
+	for _, info := range infos {
+		result = append(result, *info)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return result
+}
+
+// parseTypeInfosFromFile returns the exported top-level types of the file.
+func parseTypeInfosFromFile(fileAst *ast.File) []TypeInfo {
+	var types []TypeInfo
+
+	for _, decl := range fileAst.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			continue
+		}
+
+		for _, spec := range genDecl.Specs {
+			ts := spec.(*ast.TypeSpec)
+			if !ts.Name.IsExported() {
+				continue
+			}
+
+			types = append(types, TypeInfo{
+				Name: ts.Name.Name,
+				Kind: declaredTypeKind(ts.Type),
+			})
+		}
+	}
+
+	return types
+}
+
+func declaredTypeKind(expr ast.Expr) string {
+	switch expr.(type) {
+	case *ast.StructType:
+		return TypeKindStruct
+	case *ast.InterfaceType:
+		return TypeKindInterface
+	case *ast.FuncType:
+		return TypeKindFunc
+	case *ast.MapType:
+		return TypeKindMap
+	case *ast.ArrayType:
+		return TypeKindArray
+	case *ast.ChanType:
+		return TypeKindChan
+	case *ast.StarExpr:
+		return TypeKindStar
+	case *ast.SelectorExpr:
+		return TypeKindSelector
+	default:
+		return TypeKindIdent
+	}
+}
@@ -0,0 +1,273 @@
+package generator
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+type sourcePackage struct {
+	name       string
+	importPath string
+	fileSet    *token.FileSet
+	files      []*ast.File
+	types      map[string]struct{}
+
+	// types which can't be referenced from the other packages
+	unexported map[string]struct{}
+
+	// files only used to look up the type declarations
+	siblings []*ast.File
+
+	readFile func(filename string) ([]byte, error)
+}
+
+func newSourcePackage(importPath string, readFile func(string) ([]byte, error)) *sourcePackage {
+	return &sourcePackage{
+		importPath: importPath,
+		fileSet:    token.NewFileSet(),
+		types:      make(map[string]struct{}),
+		unexported: make(map[string]struct{}),
+		readFile:   readFile,
+	}
+}
+
+func parsePackage(importPath string, files []string) (*sourcePackage, error) {
+	return parsePackageWith(os.ReadFile, importPath, files)
+}
+
+func parsePackageWith(readFile func(string) ([]byte, error), importPath string, files []string) (*sourcePackage, error) {
+	pkg := newSourcePackage(importPath, readFile)
+
+	parsed, err := pkg.parseFiles(files, runtime.GOMAXPROCS(0))
+	if err != nil {
+		return nil, err
+	}
+
+	pkg.files = parsed
+
+	return pkg, nil
+}
+
+// addSiblings parses the rest of the package files, so the types declared
+// there are resolved, but their methods are not collected.
+func (p *sourcePackage) addSiblings(files []string) error {
+	parsed, err := p.parseFiles(files, runtime.GOMAXPROCS(0))
+	if err != nil {
+		return err
+	}
+
+	p.siblings = append(p.siblings, parsed...)
+
+	return nil
+}
+
+// parseFiles parses up to the given number of files at once. The result
+// is in the order of the files, as is the error of the first one failed,
+// so the package doesn't depend on which file is parsed sooner.
+func (p *sourcePackage) parseFiles(files []string, workers int) ([]*ast.File, error) {
+	parsed := make([]*ast.File, len(files))
+	errs := make([]error, len(files))
+
+	var (
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, workers)
+	)
+
+	for i, f := range files {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func(i int, filename string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			// the file set is safe for concurrent use
+			parsed[i], errs[i] = p.parseFile(filename)
+		}(i, f)
+	}
+
+	wg.Wait()
+
+	for i := range parsed {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+	}
+
+	if p.name == "" {
+		p.name = sourcePackageName(parsed)
+	}
+
+	own := make([]*ast.File, 0, len(parsed))
+
+	for _, f := range parsed {
+		// the external test package refers to
+		// the source one, its methods are its own
+		if identName(f.Name) != p.name {
+			continue
+		}
+
+		p.addDeclarations(f)
+		own = append(own, f)
+	}
+
+	return own, nil
+}
+
+// sourcePackageName is the package name of the files, the external test
+// package, api_test next to api, only counts if there is no other.
+func sourcePackageName(files []*ast.File) string {
+	for _, f := range files {
+		if name := identName(f.Name); !strings.HasSuffix(name, "_test") {
+			return name
+		}
+	}
+
+	if len(files) > 0 {
+		return identName(files[0].Name)
+	}
+
+	return ""
+}
+
+func (p *sourcePackage) parseFile(filename string) (*ast.File, error) {
+	src, err := p.readFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	// the filename is kept for the positions to be ordered by
+	return parser.ParseFile(p.fileSet, filename, src, parser.ParseComments)
+}
+
+// addDeclarations registers the types declared in the file.
+func (p *sourcePackage) addDeclarations(parsed *ast.File) {
+	for _, t := range parseTypesFromFile(parsed) {
+		p.types[t] = struct{}{}
+	}
+
+	for _, t := range parseUnexportedTypesFromFile(parsed) {
+		p.unexported[t] = struct{}{}
+	}
+}
+
+// lookupType finds a type declaration along with
+// the file it is declared in.
+func (p *sourcePackage) lookupType(name string) (*ast.TypeSpec, *ast.File) {
+	for _, f := range append(p.files[:len(p.files):len(p.files)], p.siblings...) {
+		for _, decl := range f.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.TYPE {
+				continue
+			}
+
+			for _, spec := range genDecl.Specs {
+				if ts := spec.(*ast.TypeSpec); ts.Name.Name == name {
+					return ts, f
+				}
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// underlyingType follows the defined types over the other types of the
+// package, type AdminClient Client, to the declaration of the one they
+// are defined by, which fields they have but not the methods.
+func (p *sourcePackage) underlyingType(spec *ast.TypeSpec, file *ast.File) (*ast.TypeSpec, *ast.File) {
+	seen := make(map[string]struct{})
+
+	for {
+		ident, ok := unparen(spec.Type).(*ast.Ident)
+		if !ok {
+			return spec, file
+		}
+
+		// a cyclic definition doesn't compile anyway
+		if _, ok := seen[ident.Name]; ok {
+			return spec, file
+		}
+		seen[ident.Name] = struct{}{}
+
+		next, nextFile := p.lookupType(ident.Name)
+		if next == nil {
+			return spec, file
+		}
+		spec, file = next, nextFile
+	}
+}
+
+// resolveAlias follows the aliases of the package types,
+// so type C = Client resolves C to Client.
+func (p *sourcePackage) resolveAlias(name string) string {
+	seen := make(map[string]struct{})
+
+	for {
+		spec, _ := p.lookupType(name)
+		if spec == nil || !spec.Assign.IsValid() {
+			return name
+		}
+
+		ident, ok := unparen(spec.Type).(*ast.Ident)
+		if !ok {
+			return name
+		}
+
+		// a cyclic alias doesn't compile anyway
+		if _, ok := seen[name]; ok {
+			return name
+		}
+		seen[name] = struct{}{}
+
+		name = ident.Name
+	}
+}
+
+// packageCache keeps the packages parsed during a run, so generating several
+// interfaces from the same files, see GenerateAll, reads every file once.
+type packageCache struct {
+	// mocked in tests to count the reads
+	readFile func(filename string) ([]byte, error)
+
+	// the file names -> the package parsed from them
+	sources map[string]*sourcePackage
+
+	// import path -> the package found with ResolvePackage
+	imports map[string]*sourcePackage
+}
+
+func newPackageCache() *packageCache {
+	return &packageCache{
+		readFile: os.ReadFile,
+		sources:  make(map[string]*sourcePackage),
+		imports:  make(map[string]*sourcePackage),
+	}
+}
+
+// source returns the package parsed from the files and the siblings.
+// The packages are cached by the names of the files in their order.
+func (c *packageCache) source(importPath string, files, siblings []string) (*sourcePackage, error) {
+	key := importPath + "\x00" + strings.Join(files, "\x00") + "\x00\x00" + strings.Join(siblings, "\x00")
+	if pkg, ok := c.sources[key]; ok {
+		return pkg, nil
+	}
+
+	pkg, err := parsePackageWith(c.readFile, importPath, files)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := pkg.addSiblings(siblings); err != nil {
+		return nil, err
+	}
+
+	c.sources[key] = pkg
+
+	return pkg, nil
+}
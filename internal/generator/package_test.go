@@ -0,0 +1,112 @@
+package generator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testWritePackage writes a package of the given number of files,
+// each declaring a type and a method of Client using it.
+func testWritePackage(t testing.TB, files int) []string {
+	dir := t.TempDir()
+
+	var b strings.Builder
+	b.WriteString("package client\n\ntype Client struct{}\n")
+
+	filenames := []string{filepath.Join(dir, "client.go")}
+	require.NoError(t, os.WriteFile(filenames[0], []byte(b.String()), 0644))
+
+	for i := 0; i < files-1; i++ {
+		b.Reset()
+		fmt.Fprintf(&b, "package client\n\nimport \"context\"\n\n")
+		fmt.Fprintf(&b, "type Item%d struct{}\n\ntype item%d struct{}\n\n", i, i)
+		fmt.Fprintf(&b, "// Get%d returns the item.\n", i)
+		fmt.Fprintf(&b, "func (c *Client) Get%d(ctx context.Context, keys ...string) (map[string][]*Item%d, error) {\n\treturn nil, nil\n}\n", i, i)
+
+		filename := filepath.Join(dir, fmt.Sprintf("item%d.go", i))
+		require.NoError(t, os.WriteFile(filename, []byte(b.String()), 0644))
+		filenames = append(filenames, filename)
+	}
+
+	return filenames
+}
+
+func testRenderPackage(t *testing.T, files []string, workers int) (*sourcePackage, string) {
+	pkg := newSourcePackage("github.com/acme/client", os.ReadFile)
+
+	parsed, err := pkg.parseFiles(files, workers)
+	require.NoError(t, err)
+	pkg.files = parsed
+
+	methods, err := newMethodCollector(Options{}).collect(pkg, "Client")
+	require.NoError(t, err)
+
+	receivers := make([]Receiver, len(methods))
+	for i, m := range methods {
+		receivers[i] = m.Receiver
+	}
+
+	code, err := RenderInterface(Options{
+		StructName:        "Client",
+		InterfaceName:     "Client",
+		OutputPackageName: "mocks",
+		GroupImports:      true,
+	}, "", nil, receivers)
+	require.NoError(t, err)
+
+	return pkg, string(code)
+}
+
+func TestParseFilesConcurrently(t *testing.T) {
+	files := testWritePackage(t, 50)
+
+	t.Run("same as sequential", func(t *testing.T) {
+		// act
+		sequential, want := testRenderPackage(t, files, 1)
+		concurrent, got := testRenderPackage(t, files, 8)
+
+		// assert
+		require.Equal(t, want, got)
+		require.Equal(t, sequential.name, concurrent.name)
+		require.Equal(t, sequential.types, concurrent.types)
+		require.Equal(t, sequential.unexported, concurrent.unexported)
+		require.Len(t, concurrent.files, len(files))
+		for i, f := range concurrent.files {
+			require.Equal(t, files[i], concurrent.fileSet.Position(f.Pos()).Filename)
+		}
+	})
+
+	t.Run("first error in the order of the files", func(t *testing.T) {
+		broken := append([]string{}, files...)
+		broken[10] = filepath.Join(t.TempDir(), "missing10.go")
+		broken[20] = filepath.Join(t.TempDir(), "missing20.go")
+
+		// act
+		_, err := parsePackage("github.com/acme/client", broken)
+
+		// assert
+		require.ErrorContains(t, err, "missing10.go")
+	})
+}
+
+func BenchmarkParsePackage(b *testing.B) {
+	files := testWritePackage(b, 200)
+
+	for _, workers := range []int{1, 8} {
+		workers := workers
+
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				pkg := newSourcePackage("github.com/acme/client", os.ReadFile)
+				if _, err := pkg.parseFiles(files, workers); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
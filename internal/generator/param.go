@@ -2,66 +2,99 @@ package generator
 
 import (
 	"go/ast"
+	"strings"
 )
 
 type Param struct {
 	Name string
 	Type *Type
+
+	// For struct fields only
+	Tag string
 }
 
 func (p Param) String() string {
-	if p.Name == "" {
-		return p.Type.String()
+	var b strings.Builder
+	p.writeTo(&b)
+	return b.String()
+}
+
+func (p Param) writeTo(b *strings.Builder) {
+	if p.Name != "" {
+		b.WriteString(p.Name)
+		b.WriteString(" ")
+	}
+	p.Type.writeTo(b)
+	if p.Tag != "" {
+		b.WriteString(" ")
+		b.WriteString(p.Tag)
 	}
-	return p.Name + " " + p.Type.String()
 }
 
-func ParseMany(list []*ast.Field, declaredTypesMap map[string]struct{}, sourcePackageName string) []*Param {
+func ParseMany(list []*ast.Field, declaredTypesMap map[string]struct{}, sourcePackageName string) ([]*Param, error) {
 	if len(list) == 0 {
-		return nil
+		return nil, nil
 	}
 
 	params := make([]*Param, 0, len(list))
 
 	for _, p := range list {
-		parsed := Parse(p, declaredTypesMap, sourcePackageName)
+		parsed, err := Parse(p, declaredTypesMap, sourcePackageName)
+		if err != nil {
+			return nil, err
+		}
 		params = append(params, parsed...)
 	}
 
-	return params
+	return params, nil
 }
 
 func Parse(
 	field *ast.Field,
 	typesMap map[string]struct{},
 	sourcePackageName string,
-) []*Param {
+) ([]*Param, error) {
 	params := make([]*Param, 0, len(field.Names))
 
+	var tag string
+	if field.Tag != nil {
+		tag = field.Tag.Value
+	}
+
 	if field.Names == nil {
+		typ, err := ParseType(field.Type, typesMap, sourcePackageName)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkRendered(field.Type, typ); err != nil {
+			return nil, err
+		}
+
 		param := &Param{
 			Name: "",
-			Type: ParseType(
-				field.Type,
-				typesMap,
-				sourcePackageName,
-			),
+			Type: typ,
+			Tag:  tag,
 		}
 		params = append(params, param)
 	}
 
 	for _, name := range field.Names {
+		typ, err := ParseType(field.Type, typesMap, sourcePackageName)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkRendered(field.Type, typ); err != nil {
+			return nil, err
+		}
+
 		param := &Param{
 			Name: name.Name,
-			Type: ParseType(
-				field.Type,
-				typesMap,
-				sourcePackageName,
-			),
+			Type: typ,
+			Tag:  tag,
 		}
 
 		params = append(params, param)
 	}
 
-	return params
+	return params, nil
 }
@@ -4,6 +4,7 @@ import (
 	"go/ast"
 	"go/parser"
 	"go/token"
+	"strings"
 	"testing"
 
 	assert "github.com/stretchr/testify/require"
@@ -21,12 +22,19 @@ func testParseType(t *testing.T, src string) *ast.Field {
 	return f.Decls[0].(*ast.FuncDecl).Type.Params.List[0]
 }
 
+func testParse(t *testing.T, field *ast.Field, typesMap map[string]struct{}) []*Param {
+	t.Helper()
+	params, err := Parse(field, typesMap, "awesomepkg")
+	assert.NoError(t, err)
+	return params
+}
+
 func TestParamString(t *testing.T) {
 	t.Run("ident", func(t *testing.T) {
 		f := testParseType(t, `a int`)
 
 		// act
-		param := Parse(f, nil, "awesomepkg")
+		param := testParse(t, f, nil)
 
 		// assert
 		assert.Equal(t, "a int", param[0].String())
@@ -36,17 +44,28 @@ func TestParamString(t *testing.T) {
 		f := testParseType(t, `a somepackage.A`)
 
 		// act
-		param := Parse(f, nil, "awesomepkg")
+		param := testParse(t, f, nil)
 
 		// assert
 		assert.Equal(t, "a somepackage.A", param[0].String())
 	})
 
+	t.Run("interface selector", func(t *testing.T) {
+		f := testParseType(t, `a io.Closer`)
+
+		// act
+		param := testParse(t, f, nil)[0]
+
+		// assert
+		assert.Equal(t, TypeKindSelector, param.Type.Kind)
+		assert.Equal(t, "a io.Closer", param.String())
+	})
+
 	t.Run("star selector", func(t *testing.T) {
 		f := testParseType(t, `a *somepackage.A`)
 
 		// act
-		param := Parse(f, nil, "awesomepkg")
+		param := testParse(t, f, nil)
 
 		// assert
 		assert.Equal(t, "a *somepackage.A", param[0].String())
@@ -56,7 +75,7 @@ func TestParamString(t *testing.T) {
 		f := testParseType(t, `a ...int`)
 
 		// act
-		param := Parse(f, nil, "awesomepkg")
+		param := testParse(t, f, nil)
 
 		// assert
 		assert.Equal(t, "a ...int", param[0].String())
@@ -66,7 +85,7 @@ func TestParamString(t *testing.T) {
 		f := testParseType(t, `a ...*int`)
 
 		// act
-		param := Parse(f, nil, "awesomepkg")
+		param := testParse(t, f, nil)
 
 		// assert
 		assert.Equal(t, "a ...*int", param[0].String())
@@ -76,7 +95,7 @@ func TestParamString(t *testing.T) {
 		f := testParseType(t, `a []int`)
 
 		// act
-		param := Parse(f, nil, "awesomepkg")
+		param := testParse(t, f, nil)
 
 		// assert
 		assert.Equal(t, "a []int", param[0].String())
@@ -86,7 +105,7 @@ func TestParamString(t *testing.T) {
 		f := testParseType(t, `a *[]int`)
 
 		// act
-		param := Parse(f, nil, "awesomepkg")
+		param := testParse(t, f, nil)
 
 		// assert
 		assert.Equal(t, "a *[]int", param[0].String())
@@ -96,7 +115,7 @@ func TestParamString(t *testing.T) {
 		f := testParseType(t, `a []*int`)
 
 		// act
-		param := Parse(f, nil, "awesomepkg")
+		param := testParse(t, f, nil)
 
 		// assert
 		assert.Equal(t, "a []*int", param[0].String())
@@ -106,7 +125,7 @@ func TestParamString(t *testing.T) {
 		f := testParseType(t, `a func(m int, d bool) error`)
 
 		// act
-		param := Parse(f, nil, "awesomepkg")
+		param := testParse(t, f, nil)
 
 		// assert
 		assert.Equal(t, "a func(m int, d bool) error", param[0].String())
@@ -116,11 +135,41 @@ func TestParamString(t *testing.T) {
 		f := testParseType(t, `a func(m int, d bool) (string, error)`)
 
 		// act
-		param := Parse(f, nil, "awesomepkg")
+		param := testParse(t, f, nil)
 
 		// assert
 		assert.Equal(t, "a func(m int, d bool) (string, error)", param[0].String())
 	})
+
+	t.Run("map of slices", func(t *testing.T) {
+		f := testParseType(t, `m map[string][]T`)
+
+		// act
+		param := testParse(t, f, map[string]struct{}{"T": {}})
+
+		// assert
+		assert.Equal(t, "m map[string][]awesomepkg.T", param[0].String())
+	})
+
+	t.Run("slice of maps", func(t *testing.T) {
+		f := testParseType(t, `s []map[K]V`)
+
+		// act
+		param := testParse(t, f, map[string]struct{}{"K": {}, "V": {}})
+
+		// assert
+		assert.Equal(t, "s []map[awesomepkg.K]awesomepkg.V", param[0].String())
+	})
+
+	t.Run("func of nested maps and slices", func(t *testing.T) {
+		f := testParseType(t, `fn func(m map[string][]pkg.Item, s []map[K]*pkg.Item) ([]map[string][]K, error)`)
+
+		// act
+		param := testParse(t, f, map[string]struct{}{"K": {}})
+
+		// assert
+		assert.Equal(t, "fn func(m map[string][]pkg.Item, s []map[awesomepkg.K]*pkg.Item) ([]map[string][]awesomepkg.K, error)", param[0].String())
+	})
 }
 
 func TestParseParam(t *testing.T) {
@@ -128,7 +177,7 @@ func TestParseParam(t *testing.T) {
 		f := testParseType(t, `a int`)
 
 		// act
-		param := Parse(f, nil, "awesomepkg")[0]
+		param := testParse(t, f, nil)[0]
 
 		// assert
 		typ := param.Type
@@ -141,7 +190,7 @@ func TestParseParam(t *testing.T) {
 		f := testParseType(t, `a somepackage.A`)
 
 		// act
-		param := Parse(f, nil, "awesomepkg")[0]
+		param := testParse(t, f, nil)[0]
 
 		// assert
 		assert.Equal(t, "a", param.Name)
@@ -152,11 +201,22 @@ func TestParseParam(t *testing.T) {
 		assert.Nil(t, typ.Child)
 	})
 
+	t.Run("interface selector", func(t *testing.T) {
+		f := testParseType(t, `a io.Closer`)
+
+		// act
+		param := testParse(t, f, nil)[0]
+
+		// assert
+		assert.Equal(t, TypeKindSelector, param.Type.Kind)
+		assert.Equal(t, "a io.Closer", param.String())
+	})
+
 	t.Run("star selector", func(t *testing.T) {
 		f := testParseType(t, `a *somepackage.A`)
 
 		// act
-		param := Parse(f, nil, "awesomepkg")[0]
+		param := testParse(t, f, nil)[0]
 
 		// assert
 		assert.Equal(t, "a", param.Name)
@@ -173,7 +233,7 @@ func TestParseParam(t *testing.T) {
 		f := testParseType(t, `a ...int`)
 
 		// act
-		param := Parse(f, nil, "awesomepkg")[0]
+		param := testParse(t, f, nil)[0]
 
 		// assert
 		assert.Equal(t, "a", param.Name)
@@ -189,7 +249,7 @@ func TestParseParam(t *testing.T) {
 		f := testParseType(t, `a ...*int`)
 
 		// act
-		param := Parse(f, nil, "awesomepkg")[0]
+		param := testParse(t, f, nil)[0]
 
 		// assert
 		assert.Equal(t, "a", param.Name)
@@ -207,7 +267,7 @@ func TestParseParam(t *testing.T) {
 		f := testParseType(t, `a []int`)
 
 		// act
-		param := Parse(f, nil, "awesomepkg")[0]
+		param := testParse(t, f, nil)[0]
 
 		// assert
 		assert.Equal(t, "a", param.Name)
@@ -223,7 +283,7 @@ func TestParseParam(t *testing.T) {
 		f := testParseType(t, `a *[]int`)
 
 		// act
-		param := Parse(f, nil, "awesomepkg")[0]
+		param := testParse(t, f, nil)[0]
 
 		// assert
 		assert.Equal(t, "a", param.Name)
@@ -241,7 +301,7 @@ func TestParseParam(t *testing.T) {
 		f := testParseType(t, `a []*int`)
 
 		// act
-		param := Parse(f, nil, "awesomepkg")[0]
+		param := testParse(t, f, nil)[0]
 
 		// assert
 		assert.Equal(t, "a", param.Name)
@@ -255,11 +315,53 @@ func TestParseParam(t *testing.T) {
 		assert.Nil(t, child.Child)
 	})
 
+	t.Run("union", func(t *testing.T) {
+		f := testParseType(t, `a interface{ int | string | somepackage.ID }`)
+		union := f.Type.(*ast.InterfaceType).Methods.List[0]
+
+		// act
+		param := testParse(t, union, nil)
+
+		// assert
+		assert.Equal(t, "int | string | somepackage.ID", param[0].String())
+	})
+
+	t.Run("approximation union", func(t *testing.T) {
+		f := testParseType(t, `a interface{ ~int | ~string | ~somepackage.ID }`)
+		union := f.Type.(*ast.InterfaceType).Methods.List[0]
+
+		// act
+		param := testParse(t, union, nil)
+
+		// assert
+		assert.Equal(t, "~int | ~string | ~somepackage.ID", param[0].String())
+	})
+
+	t.Run("empty struct", func(t *testing.T) {
+		f := testParseType(t, `a struct{}`)
+
+		// act
+		param := testParse(t, f, nil)
+
+		// assert
+		assert.Equal(t, "a struct{}", param[0].String())
+	})
+
+	t.Run("struct", func(t *testing.T) {
+		f := testParseType(t, `a struct{ OK bool; Item *T "json:\"item\"" }`)
+
+		// act
+		param := testParse(t, f, map[string]struct{}{"T": {}})
+
+		// assert
+		assert.Equal(t, `a struct{ OK bool; Item *awesomepkg.T "json:\"item\"" }`, param[0].String())
+	})
+
 	t.Run("func", func(t *testing.T) {
 		f := testParseType(t, `a func(m int, d bool) error`)
 
 		// act
-		param := Parse(f, nil, "awesomepkg")[0]
+		param := testParse(t, f, nil)[0]
 
 		// assert
 		assert.Equal(t, "a", param.Name)
@@ -280,3 +382,369 @@ func TestParseParam(t *testing.T) {
 		assert.Equal(t, "error", e.Type.Name)
 	})
 }
+
+func TestParseNestedMapParam(t *testing.T) {
+	f := testParseType(t, `m map[string][]pkg.Item`)
+
+	// act
+	param := testParse(t, f, nil)[0]
+
+	// assert
+	assert.Equal(t, TypeKindMap, param.Type.Kind)
+	assert.Equal(t, "string", param.Type.mapKeyType.Name)
+
+	value := param.Type.mapValType
+	assert.Equal(t, TypeKindArray, value.Kind)
+	assert.Equal(t, TypeKindSelector, value.Child.Kind)
+	assert.Equal(t, "pkg", value.Child.Package)
+	assert.Equal(t, "Item", value.Child.Name)
+}
+
+func TestNestedQualifiedTypes(t *testing.T) {
+	cases := []struct {
+		src  string
+		want string
+
+		// the qualified types reached by the walk, if checked
+		qualified []string
+	}{
+		{src: `chan map[string]*pkg.T`, want: `chan map[string]*pkg.T`},
+		{src: `[]chan *pkg.T`, want: `[]chan *pkg.T`},
+		{src: `map[pkg.K]chan<- V`, want: `map[pkg.K]chan<- awesomepkg.V`},
+		{src: `func(chan []pkg.T)`, want: `func(chan []pkg.T)`},
+		{src: `<-chan func() map[pkg.K][]*pkg.T`, want: `<-chan func() map[pkg.K][]*pkg.T`},
+		{src: `*[]map[*pkg.K]chan func(...pkg.T) (pkg.T, error)`, want: `*[]map[*pkg.K]chan func(...pkg.T) (pkg.T, error)`},
+		{src: `func() <-chan pkg.Event`, want: `func() <-chan pkg.Event`},
+		{src: `func() chan<- *pkg.Event`, want: `func() chan<- *pkg.Event`},
+		{src: `chan map[pkg.K]pkg.V`, want: `chan map[pkg.K]pkg.V`, qualified: []string{"K", "V"}},
+		{src: `map[pkg.K][]pkg.V`, want: `map[pkg.K][]pkg.V`, qualified: []string{"K", "V"}},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.src, func(t *testing.T) {
+			f := testParseType(t, "a "+tc.src)
+
+			// act
+			param := testParse(t, f, map[string]struct{}{"V": {}})[0]
+
+			// assert
+			assert.Equal(t, tc.want, param.Type.String())
+
+			// the import collection reaches the nested types
+			var qualified []string
+			param.Type.walk(func(t *Type) {
+				if t.Package == "pkg" {
+					qualified = append(qualified, t.Name)
+				}
+			})
+			assert.NotEmpty(t, qualified)
+			if tc.qualified != nil {
+				assert.ElementsMatch(t, tc.qualified, qualified)
+			}
+		})
+	}
+}
+
+func TestPredeclaredTypesUnqualified(t *testing.T) {
+	f := testParseType(t, `a func(any, int, float64, ...byte) (map[string]Item, error)`)
+
+	// act
+	typesMap := map[string]struct{}{"any": {}, "int": {}, "float64": {}, "byte": {}, "string": {}, "error": {}, "Item": {}}
+	param := testParse(t, f, typesMap)[0]
+
+	// assert
+	assert.Equal(t, "a func(any, int, float64, ...byte) (map[string]awesomepkg.Item, error)", param.String())
+}
+
+func TestVariadicComposite(t *testing.T) {
+	cases := []struct {
+		src     string
+		widened string
+	}{
+		{src: `...[]int`, widened: `[][]int`},
+		{src: `...map[K]V`, widened: `[]map[K]V`},
+		{src: `...*T`, widened: `[]*T`},
+		{src: `...map[string][]*pkg.T`, widened: `[]map[string][]*pkg.T`},
+		{src: `...func(int) error`, widened: `[]func(int) error`},
+		{src: `...chan<- int`, widened: `[]chan<- int`},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.src, func(t *testing.T) {
+			fset := token.NewFileSet()
+			f, err := parser.ParseFile(fset, "", "package awesomepkg; func some(a "+tc.src+") {}", 0)
+			assert.NoError(t, err)
+			field := f.Decls[0].(*ast.FuncDecl).Type.Params.List[0]
+			params := testParse(t, field, nil)
+
+			// act
+			rendered := params[0].Type.String()
+			widenVariadic(Receiver{Params: params})
+
+			// assert
+			assert.Equal(t, formatNode(fset, field.Type), rendered)
+			assert.Equal(t, tc.widened, params[0].Type.String())
+		})
+	}
+}
+
+func TestUnsupportedType(t *testing.T) {
+	f := testParseType(t, `a [2 * N]int`)
+
+	// act
+	_, err := Parse(f, nil, "awesomepkg")
+
+	// assert
+	var unsupported *UnsupportedTypeError
+	assert.ErrorAs(t, err, &unsupported)
+	assert.Equal(t, f.Type, unsupported.Node)
+}
+
+func TestUnsupportedOperators(t *testing.T) {
+	// go/parser never produces these in the type positions, the other tools building the AST may
+	cases := map[string]ast.Expr{
+		"binary": &ast.BinaryExpr{X: ast.NewIdent("int"), Op: token.AND, Y: ast.NewIdent("string")},
+		"unary":  &ast.UnaryExpr{Op: token.SUB, X: ast.NewIdent("int")},
+	}
+
+	for name, node := range cases {
+		node := node
+
+		t.Run(name, func(t *testing.T) {
+			// act
+			_, err := ParseType(&ast.ArrayType{Elt: node}, nil, "awesomepkg")
+
+			// assert
+			var unsupported *UnsupportedTypeError
+			assert.ErrorAs(t, err, &unsupported)
+			assert.Equal(t, node, unsupported.Node)
+		})
+	}
+}
+
+func TestNestedSelector(t *testing.T) {
+	// go/parser never produces these, the other tools building the AST may
+	cases := map[string]struct {
+		x    ast.Expr
+		want string
+	}{
+		"selector": {
+			x:    &ast.SelectorExpr{X: ast.NewIdent("outer"), Sel: ast.NewIdent("inner")},
+			want: "outer.inner.Type",
+		},
+		"paren": {
+			x:    &ast.ParenExpr{X: ast.NewIdent("pkg")},
+			want: "(pkg).Type",
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			node := &ast.SelectorExpr{X: tc.x, Sel: ast.NewIdent("Type")}
+
+			// act
+			_, err := ParseType(&ast.ArrayType{Elt: node}, nil, "awesomepkg")
+
+			// assert
+			var unsupported *UnsupportedTypeError
+			assert.ErrorAs(t, err, &unsupported)
+			assert.Equal(t, node, unsupported.Node)
+			assert.EqualError(t, err, "unsupported qualified identifier "+tc.want+", the qualifier has to be a package name")
+		})
+	}
+}
+
+func TestInterfaceLiteral(t *testing.T) {
+	cases := []struct {
+		src  string
+		want string
+	}{
+		{src: `c interface{}`, want: "c interface{}"},
+		{src: `c interface{ Close() error }`, want: "c interface{ Close() error }"},
+		{src: `c interface{ Get(key string) (*T, error); io.Reader }`, want: "c interface{ Get(key string) (*awesomepkg.T, error); io.Reader }"},
+		{src: `c interface{ ~[]*T | somepackage.A }`, want: "c interface{ ~[]*awesomepkg.T | somepackage.A }"},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.src, func(t *testing.T) {
+			f := testParseType(t, tc.src)
+
+			// act
+			param := testParse(t, f, map[string]struct{}{"T": {}})[0]
+
+			// assert
+			assert.Equal(t, tc.want, param.String())
+
+			_, err := parser.ParseExpr(param.Type.String())
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestUseAny(t *testing.T) {
+	cases := []struct {
+		src    string
+		useAny bool
+		want   string
+	}{
+		{src: `args ...any`, useAny: false, want: "args ...any"},
+		{src: `args ...any`, useAny: true, want: "args ...any"},
+		{src: `args ...interface{}`, useAny: false, want: "args ...interface{}"},
+		{src: `args ...interface{}`, useAny: true, want: "args ...any"},
+		{src: `m map[string][]interface{}`, useAny: true, want: "m map[string][]any"},
+		{src: `c interface{ Close() error }`, useAny: true, want: "c interface{ Close() error }"},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.want, func(t *testing.T) {
+			f := testParseType(t, tc.src)
+			r := Receiver{Params: testParse(t, f, nil)}
+
+			// act
+			if tc.useAny {
+				useAny(r)
+			}
+
+			// assert
+			assert.Equal(t, tc.want, r.Params[0].String())
+		})
+	}
+}
+
+func TestPointerResultMatchesPrinter(t *testing.T) {
+	cases := []string{
+		`*int`,
+		`**int`,
+		`*T`,
+		`*somepackage.A`,
+		`[]*T`,
+		`*[]*T`,
+		`map[string]*T`,
+		`map[*T][]*int`,
+		`func() *T`,
+		`func(a *int) (*T, error)`,
+		`func(a *int)`,
+		`func() (t *T)`,
+		`chan *T`,
+		`<-chan *somepackage.A`,
+		`chan (<-chan *T)`,
+		`(*T)`,
+		`[]([]T)`,
+	}
+
+	for _, src := range cases {
+		src := src
+
+		t.Run(src, func(t *testing.T) {
+			fset := token.NewFileSet()
+			f, err := parser.ParseFile(fset, "", "package awesomepkg; func some() ("+src+") {}", 0)
+			assert.NoError(t, err)
+			field := f.Decls[0].(*ast.FuncDecl).Type.Results.List[0]
+
+			// act
+			rendered := testParse(t, field, map[string]struct{}{"T": {}})[0].Type.String()
+
+			// assert
+			// the source package types are qualified when rendered
+			assert.Equal(t, formatNode(fset, field.Type), strings.ReplaceAll(rendered, "awesomepkg.", ""))
+
+			expr, err := parser.ParseExpr(rendered)
+			assert.NoError(t, err)
+			assert.Equal(t, rendered, formatNode(token.NewFileSet(), expr))
+		})
+	}
+}
+
+func TestFixedSizeArrays(t *testing.T) {
+	cases := map[string]string{
+		`a [32]byte`:        `a [32]byte`,
+		`b ...[2]int`:       `b ...[2]int`,
+		`c [N]T`:            `c [awesomepkg.N]awesomepkg.T`,
+		`d [pkg.N][]string`: `d [pkg.N][]string`,
+		`e map[[4]int][]T`:  `e map[[4]int][]awesomepkg.T`,
+	}
+
+	for src, want := range cases {
+		src, want := src, want
+
+		t.Run(src, func(t *testing.T) {
+			f := testParseType(t, src)
+
+			// act
+			params := testParse(t, f, map[string]struct{}{"T": {}})
+
+			// assert
+			assert.Equal(t, want, params[0].String())
+		})
+	}
+}
+
+func TestCheckRendered(t *testing.T) {
+	f := testParseType(t, `a [4]string`)
+
+	// act
+	err := checkRendered(f.Type, &Type{Kind: TypeKindArray, Child: &Type{Name: "string", Kind: TypeKindIdent}})
+
+	// assert
+	assert.EqualError(t, err, "type [4]string is rendered as []string")
+}
+
+// testNestedTypeSource wraps a type into the composite ones depth times.
+func testNestedTypeSource(depth int) string {
+	wrappers := []string{
+		"map[string]%s",
+		"[]*%s",
+		"func(ctx context.Context, keys ...string) (%s, error)",
+		"<-chan %s",
+		"struct{ V %s }",
+		"Pair[string, %s]",
+	}
+
+	src := "somepackage.A"
+	for i := 0; i < depth; i++ {
+		src = strings.Replace(wrappers[i%len(wrappers)], "%s", src, 1)
+	}
+
+	return src
+}
+
+func TestNestedTypeString(t *testing.T) {
+	f := testParseType(t, "a "+testNestedTypeSource(24))
+
+	// act
+	rendered := testParse(t, f, nil)[0].Type.String()
+
+	// assert
+	_, err := parser.ParseExpr(rendered)
+	assert.NoError(t, err)
+	assert.Equal(t, testNestedTypeSource(24), rendered)
+}
+
+func BenchmarkNestedTypeString(b *testing.B) {
+	f, err := parser.ParseFile(token.NewFileSet(), "", "package awesomepkg; func some(a "+testNestedTypeSource(24)+")", 0)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	params, err := Parse(f.Decls[0].(*ast.FuncDecl).Type.Params.List[0], nil, "awesomepkg")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_ = params[0].Type.String()
+	}
+}
@@ -6,7 +6,10 @@ import (
 	"go/ast"
 	"go/printer"
 	"go/token"
+	"sort"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 type Receiver struct {
@@ -14,32 +17,43 @@ type Receiver struct {
 	Results []*Param
 	Name    string
 	Comment string
+
+	// where the method is declared
+	pos token.Pos
+
+	// declared on the pointer, so not in the method set of the value
+	pointer bool
+
+	// the names the receiver gives the type parameters: K and V of Cache[K, V]
+	typeParams []string
+
+	// set by the //ifacemaker: directives of the doc
+	skip   bool
+	rename string
 }
 
 func (r Receiver) String() string {
-	var (
-		params  []string
-		results []string
-	)
+	var b strings.Builder
 
-	for _, p := range r.Params {
-		params = append(params, p.String())
-	}
-
-	for _, p := range r.Results {
-		results = append(results, p.String())
-	}
+	b.WriteString(r.Comment)
+	b.WriteString(r.Name)
+	writeSignature(&b, r.Params, r.Results)
 
-	var comment string
+	return b.String()
+}
 
-	if r.Comment != "" {
-		comment = r.Comment
+// signature identifies the method by its name and the types of its
+// parameters and results, so renaming a parameter doesn't change it.
+func (r Receiver) signature() string {
+	types := func(params []*Param) string {
+		names := make([]string, len(params))
+		for i, p := range params {
+			names[i] = p.Type.String()
+		}
+		return strings.Join(names, ", ")
 	}
 
-	if len(r.Results) > 0 {
-		return fmt.Sprintf(comment+"%s(%s)(%s)", r.Name, strings.Join(params, ", "), strings.Join(results, ", "))
-	}
-	return fmt.Sprintf(comment+"%s(%s)", r.Name, strings.Join(params, ", "))
+	return r.Name + "(" + types(r.Params) + ")(" + types(r.Results) + ")"
 }
 
 func ParseReceivers(
@@ -48,38 +62,87 @@ func ParseReceivers(
 	structName string,
 	sourcePackageName string,
 	declaredTypesMap map[string]struct{},
-) []Receiver {
-	var receivers []Receiver
+) ([]Receiver, error) {
+	match := func(name string) bool {
+		return name == structName
+	}
+
+	return parseReceivers(astFile, fset, match, false, sourcePackageName, declaredTypesMap)
+}
+
+// parseReceivers collects the exported methods, the unexported ones
+// as well if it is set, which receiver type names satisfy the match.
+func parseReceivers(
+	astFile *ast.File,
+	fset *token.FileSet,
+	match func(typeName string) bool,
+	unexported bool,
+	sourcePackageName string,
+	declaredTypesMap map[string]struct{},
+) ([]Receiver, error) {
+	var (
+		receivers []Receiver
+		err       error
+	)
 
 	ast.Inspect(astFile, func(node ast.Node) bool {
+		// the first unsupported type stops the inspection
+		if err != nil {
+			return false
+		}
+
 		funcDecl, ok := node.(*ast.FuncDecl)
 		if !ok {
 			return true
 		}
 
 		// don't care about private ones
-		if !isReceiver(funcDecl) || !isFuncExported(funcDecl) {
+		if !isReceiver(funcDecl) || (!isFuncExported(funcDecl) && !unexported) {
 			return true
 		}
 
-		recvTypeName := formatNode(fset, funcDecl.Recv.List[0].Type)
-
-		// remove a star if there is any, so we
-		// can make assertions against a user-provided type
-		recvTypeName = strings.TrimPrefix(recvTypeName, "*")
-
 		// other type's receiver
-		if recvTypeName != structName {
+		if !match(receiverTypeName(fset, funcDecl)) {
 			return true
 		}
 
 		name := funcDecl.Name.String()
 
+		// func (c *Client) Get[T any]() parses, but doesn't compile
+		if funcDecl.Type.TypeParams != nil {
+			err = fmt.Errorf("%s: method %s can't have type parameters, only the functions and the types can", fset.Position(funcDecl.Type.TypeParams.Pos()), name)
+			return false
+		}
+
+		params, parseErr := ParseMany(extractList(funcDecl.Type.Params), declaredTypesMap, sourcePackageName)
+		if parseErr != nil {
+			err = positionError(fset, parseErr)
+			return false
+		}
+
+		results, parseErr := ParseMany(extractList(funcDecl.Type.Results), declaredTypesMap, sourcePackageName)
+		if parseErr != nil {
+			err = positionError(fset, parseErr)
+			return false
+		}
+
+		doc, directives, parseErr := splitDirectives(fset, extractComments(funcDecl.Doc))
+		if parseErr != nil {
+			err = parseErr
+			return false
+		}
+
 		receiver := Receiver{
-			Comment: parseReceiverDocs(extractComments(funcDecl.Doc)),
-			Params:  ParseMany(extractList(funcDecl.Type.Params), declaredTypesMap, sourcePackageName),
-			Results: ParseMany(extractList(funcDecl.Type.Results), declaredTypesMap, sourcePackageName),
+			Comment: parseReceiverDocs(doc),
+			Params:  params,
+			Results: results,
 			Name:    name,
+			pos:     funcDecl.Pos(),
+			pointer: isPointerReceiver(funcDecl),
+			skip:    directives.skip,
+			rename:  directives.rename,
+
+			typeParams: receiverTypeParams(funcDecl),
 		}
 
 		receivers = append(receivers, receiver)
@@ -87,7 +150,317 @@ func ParseReceivers(
 		return true
 	})
 
-	return receivers
+	if err != nil {
+		return nil, err
+	}
+
+	return receivers, nil
+}
+
+// replaceUnexported substitutes the unexported types, as well as the
+// pointers to them, in the receiver's signature with the replacement.
+func replaceUnexported(r Receiver, unexported map[string]struct{}, replacement string) {
+	isUnexported := func(t *Type) bool {
+		if t.Kind == TypeKindStar {
+			t = t.Child
+		}
+		_, ok := unexported[t.Name]
+		return ok && t.Kind == TypeKindIdent && t.Package == ""
+	}
+
+	visit := func(t *Type) {
+		if isUnexported(t) {
+			*t = Type{Name: replacement, Kind: TypeKindIdent}
+		}
+	}
+
+	for _, p := range r.Params {
+		p.Type.walk(visit)
+	}
+	for _, p := range r.Results {
+		p.Type.walk(visit)
+	}
+}
+
+// useAny spells the empty interfaces in the receiver's signature as any.
+func useAny(r Receiver) {
+	visit := func(t *Type) {
+		if t.empty() {
+			*t = Type{Name: "any", Kind: TypeKindIdent}
+		}
+	}
+
+	for _, p := range r.Params {
+		p.Type.walk(visit)
+	}
+	for _, p := range r.Results {
+		p.Type.walk(visit)
+	}
+}
+
+// checkVariadic fails if a signature of the receivers, their own or the one
+// of a func type they refer to, has ... elsewhere than the type of the final
+// parameter, the rendered interface wouldn't compile then.
+func checkVariadic(receivers []Receiver) error {
+	for _, r := range receivers {
+		// the ... which are the types of the final parameters
+		final := make(map[*Type]struct{})
+		markFinal := func(params []*Param) {
+			if len(params) > 0 {
+				final[params[len(params)-1].Type] = struct{}{}
+			}
+		}
+
+		markFinal(r.Params)
+		walkImportTypes(nil, []Receiver{r}, func(t *Type) {
+			markFinal(t.Params)
+		})
+
+		misplaced := false
+		walkImportTypes(nil, []Receiver{r}, func(t *Type) {
+			if _, ok := final[t]; t.Kind == TypeKindEllipsis && !ok {
+				misplaced = true
+			}
+		})
+
+		if misplaced {
+			return fmt.Errorf("method %s can only have ... as the type of the final parameter", r.Name)
+		}
+	}
+
+	return nil
+}
+
+// widenVariadic replaces the receiver's variadic parameter with a slice:
+// ...string -> []string. It reports whether there was one.
+func widenVariadic(r Receiver) bool {
+	if len(r.Params) == 0 {
+		return false
+	}
+
+	last := r.Params[len(r.Params)-1].Type
+	if last.Kind != TypeKindEllipsis {
+		return false
+	}
+
+	last.Kind = TypeKindArray
+
+	return true
+}
+
+const missingContextNote = "// Deprecated: missing context\n"
+
+// flagMissingContext adds a deprecation note to the docs of the methods
+// which don't take a context.Context as the first parameter.
+func flagMissingContext(receivers []Receiver) {
+	for i, r := range receivers {
+		if len(r.Params) > 0 && isContext(r.Params[0].Type) {
+			continue
+		}
+
+		// the note is a paragraph of its own
+		if r.Comment != "" {
+			receivers[i].Comment += "//\n"
+		}
+		receivers[i].Comment += missingContextNote
+	}
+}
+
+// errorIsLast reports whether the receiver follows the (T, error)
+// convention, it holds if there is no error result at all.
+func errorIsLast(r Receiver) bool {
+	for i, p := range r.Results {
+		if p.Type.Kind == TypeKindIdent && p.Type.Name == "error" && p.Type.Package == "" && i != len(r.Results)-1 {
+			return false
+		}
+	}
+	return true
+}
+
+func isContext(t *Type) bool {
+	if t.Kind != TypeKindSelector || t.Name != "Context" {
+		return false
+	}
+	if t.ImportPath != "" {
+		return t.ImportPath == "context"
+	}
+	return t.Package == "context"
+}
+
+// renameReceivers renames the methods by the old -> new mapping and fails if
+// the result has two methods with the same name. A doc starting with the old
+// name starts with the new one then. Unknown names are returned.
+func renameReceivers(receivers []Receiver, renames map[string]string) ([]string, error) {
+	found := make(map[string]struct{}, len(renames))
+	renamedFrom := make(map[string]string, len(receivers))
+
+	for i, r := range receivers {
+		name := r.Name
+		if newName, ok := renames[name]; ok {
+			found[name] = struct{}{}
+			name = newName
+		}
+
+		if other, ok := renamedFrom[name]; ok {
+			return nil, fmt.Errorf("methods %s and %s are both named %s after renaming", other, r.Name, name)
+		}
+
+		renamedFrom[name] = r.Name
+		receivers[i].Name = name
+		receivers[i].Comment = renameDoc(r.Comment, r.Name, name)
+	}
+
+	var unknown []string
+	for name := range renames {
+		if _, ok := found[name]; !ok {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+
+	return unknown, nil
+}
+
+// renameDoc replaces the name the doc comment starts with, as godoc expects:
+// "// Get returns the item." -> "// Load returns the item.". The name must
+// be a word of its own, "// Getter ..." is kept as it is.
+func renameDoc(comment, oldName, newName string) string {
+	prefix := "// " + oldName
+	if oldName == newName || !strings.HasPrefix(comment, prefix) {
+		return comment
+	}
+
+	rest := comment[len(prefix):]
+	if r, _ := utf8.DecodeRuneInString(rest); r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+		return comment
+	}
+
+	return "// " + newName + rest
+}
+
+// orderReceivers puts the receivers named in the order first, in that order,
+// followed by the rest sorted by name. The names not found are returned.
+func orderReceivers(receivers []Receiver, order []string) ([]Receiver, []string) {
+	rank := make(map[string]int, len(order))
+	for i, name := range order {
+		if _, ok := rank[name]; !ok {
+			rank[name] = i
+		}
+	}
+
+	found := make(map[string]struct{}, len(order))
+	for _, r := range receivers {
+		if _, ok := rank[r.Name]; ok {
+			found[r.Name] = struct{}{}
+		}
+	}
+
+	ordered := append([]Receiver(nil), receivers...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		a, aRanked := rank[ordered[i].Name]
+		b, bRanked := rank[ordered[j].Name]
+		switch {
+		case aRanked && bRanked:
+			return a < b
+		case aRanked || bRanked:
+			return aRanked
+		default:
+			return ordered[i].Name < ordered[j].Name
+		}
+	})
+
+	var unknown []string
+	for _, name := range order {
+		if _, ok := found[name]; !ok {
+			unknown = append(unknown, name)
+		}
+	}
+
+	return ordered, unknown
+}
+
+// directiveRenames adds the renames of the //ifacemaker:rename directives
+// to the given ones, which take precedence.
+func directiveRenames(receivers []Receiver, renames map[string]string) map[string]string {
+	merged := make(map[string]string, len(renames))
+
+	for _, r := range receivers {
+		if r.rename != "" {
+			merged[r.Name] = r.rename
+		}
+	}
+
+	for oldName, newName := range renames {
+		merged[oldName] = newName
+	}
+
+	return merged
+}
+
+// prefixRenames adds the renames stripping the prefix from the names of
+// the receivers, APIGetUser to GetUser for API, to the given ones, which
+// take precedence. A name left empty or changing its case is kept.
+func prefixRenames(receivers []Receiver, prefix string, renames map[string]string) map[string]string {
+	for _, r := range receivers {
+		if _, ok := renames[r.Name]; ok {
+			continue
+		}
+
+		name := strings.TrimPrefix(r.Name, prefix)
+		if name != r.Name && token.IsIdentifier(name) && token.IsExported(name) == token.IsExported(r.Name) {
+			renames[r.Name] = name
+		}
+	}
+
+	return renames
+}
+
+func receiverTypeName(fset *token.FileSet, funcDecl *ast.FuncDecl) string {
+	// the receiver is matched by its type only,
+	// it may be unnamed or named with a blank
+	recvType := unparen(funcDecl.Recv.List[0].Type)
+
+	// remove a star if there is any, so we
+	// can make assertions against a user-provided type
+	if star, ok := recvType.(*ast.StarExpr); ok {
+		recvType = unparen(star.X)
+	}
+
+	// as well as the type parameters: Cache[K, V]
+	switch t := recvType.(type) {
+	case *ast.IndexExpr:
+		recvType = t.X
+	case *ast.IndexListExpr:
+		recvType = t.X
+	}
+
+	return formatNode(fset, recvType)
+}
+
+// receiverTypeParams returns the names of the type parameters
+// of the receiver type, the blank ones included.
+func receiverTypeParams(funcDecl *ast.FuncDecl) []string {
+	recvType := unparen(funcDecl.Recv.List[0].Type)
+	if star, ok := recvType.(*ast.StarExpr); ok {
+		recvType = unparen(star.X)
+	}
+
+	var indices []ast.Expr
+	switch t := recvType.(type) {
+	case *ast.IndexExpr:
+		indices = []ast.Expr{t.Index}
+	case *ast.IndexListExpr:
+		indices = t.Indices
+	}
+
+	names := make([]string, 0, len(indices))
+	for _, index := range indices {
+		if ident, ok := index.(*ast.Ident); ok {
+			names = append(names, ident.Name)
+		}
+	}
+
+	return names
 }
 
 func extractComments(doc *ast.CommentGroup) []*ast.Comment {
@@ -97,6 +470,17 @@ func extractComments(doc *ast.CommentGroup) []*ast.Comment {
 	return doc.List
 }
 
+// unparen strips the parentheses around the type: (*Client) -> *Client.
+func unparen(expr ast.Expr) ast.Expr {
+	for {
+		paren, ok := expr.(*ast.ParenExpr)
+		if !ok {
+			return expr
+		}
+		expr = paren.X
+	}
+}
+
 func isFuncExported(n *ast.FuncDecl) bool {
 	return n.Name.IsExported()
 }
@@ -105,6 +489,11 @@ func isReceiver(n *ast.FuncDecl) bool {
 	return n.Recv != nil
 }
 
+func isPointerReceiver(n *ast.FuncDecl) bool {
+	_, ok := unparen(n.Recv.List[0].Type).(*ast.StarExpr)
+	return ok
+}
+
 func formatNode(fs *token.FileSet, node any) string {
 	var buf bytes.Buffer
 	_ = printer.Fprint(&buf, fs, node)
@@ -124,3 +513,46 @@ func parseReceiverDocs(lines []*ast.Comment) string {
 
 	return strings.TrimSuffix(strings.Join(comments, "\n"), "\n") + "\n"
 }
+
+const directivePrefix = "//ifacemaker:"
+
+// methodDirectives are the //ifacemaker: lines of a method doc:
+// //ifacemaker:skip leaves the method out of the interface and
+// //ifacemaker:rename Name gives it another name there.
+type methodDirectives struct {
+	skip   bool
+	rename string
+}
+
+// splitDirectives separates the directives from the rest of the doc,
+// the blank lines left at its end are dropped.
+func splitDirectives(fset *token.FileSet, lines []*ast.Comment) ([]*ast.Comment, methodDirectives, error) {
+	var (
+		doc        []*ast.Comment
+		directives methodDirectives
+	)
+
+	for _, line := range lines {
+		if !strings.HasPrefix(line.Text, directivePrefix) {
+			doc = append(doc, line)
+			continue
+		}
+
+		fields := strings.Fields(strings.TrimPrefix(line.Text, directivePrefix))
+
+		switch {
+		case len(fields) == 1 && fields[0] == "skip":
+			directives.skip = true
+		case len(fields) == 2 && fields[0] == "rename" && token.IsIdentifier(fields[1]) && token.IsExported(fields[1]):
+			directives.rename = fields[1]
+		default:
+			return nil, methodDirectives{}, fmt.Errorf("%s: invalid directive %s", fset.Position(line.Pos()), line.Text)
+		}
+	}
+
+	for len(doc) > 0 && strings.TrimSpace(doc[len(doc)-1].Text) == "//" {
+		doc = doc[:len(doc)-1]
+	}
+
+	return doc, directives, nil
+}
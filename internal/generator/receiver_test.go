@@ -0,0 +1,205 @@
+package generator
+
+import (
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReceiverString(t *testing.T) {
+	ident := func(name string) *Type {
+		return &Type{Name: name, Kind: TypeKindIdent}
+	}
+
+	cases := []struct {
+		name     string
+		receiver Receiver
+		want     string
+	}{
+		{
+			name:     "no params and results",
+			receiver: Receiver{Name: "Method"},
+			want:     "Method()",
+		},
+		{
+			name: "single result",
+			receiver: Receiver{
+				Name:    "Method",
+				Results: []*Param{{Type: ident("error")}},
+			},
+			want: "Method() error",
+		},
+		{
+			name: "several results",
+			receiver: Receiver{
+				Name:    "Method",
+				Results: []*Param{{Type: ident("int")}, {Type: ident("error")}},
+			},
+			want: "Method() (int, error)",
+		},
+		{
+			name: "named result",
+			receiver: Receiver{
+				Name:    "Method",
+				Results: []*Param{{Name: "err", Type: ident("error")}},
+			},
+			want: "Method() (err error)",
+		},
+		{
+			name: "params and comment",
+			receiver: Receiver{
+				Name:    "Method",
+				Comment: "// Method takes 100% of the key.\n",
+				Params:  []*Param{{Name: "key", Type: ident("string")}},
+				Results: []*Param{{Type: ident("error")}},
+			},
+			want: "// Method takes 100% of the key.\nMethod(key string) error",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			// act
+			got := tc.receiver.String()
+
+			// assert
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestChannelResult(t *testing.T) {
+	const src = `package events
+
+import "github.com/acme/pkg"
+
+type Bus struct{}
+
+func (b *Bus) Events() <-chan pkg.Event { return nil }
+
+func (b *Bus) Sink() chan<- *pkg.Event { return nil }
+`
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "bus.go", src, 0)
+	require.NoError(t, err)
+
+	// act
+	receivers, err := ParseReceivers(file, fset, "Bus", "events", nil)
+
+	// assert
+	require.NoError(t, err)
+	require.Len(t, receivers, 2)
+	require.Equal(t, "Events() <-chan pkg.Event", receivers[0].String())
+	require.Equal(t, "Sink() chan<- *pkg.Event", receivers[1].String())
+
+	events := receivers[0].Results[0].Type
+	require.Equal(t, TypeKindChan, events.Kind)
+	require.Equal(t, TypeKindSelector, events.Child.Kind)
+	require.Equal(t, "pkg", events.Child.Package)
+}
+
+func TestCheckVariadic(t *testing.T) {
+	ident := func(name string) *Type {
+		return &Type{Kind: TypeKindIdent, Name: name}
+	}
+	variadic := func(name string) *Type {
+		return &Type{Kind: TypeKindEllipsis, Child: ident(name)}
+	}
+
+	cases := []struct {
+		name    string
+		params  []*Param
+		results []*Param
+		err     string
+	}{
+		{
+			name:   "final parameter",
+			params: []*Param{{Name: "n", Type: ident("int")}, {Name: "args", Type: variadic("string")}},
+		},
+		{
+			name: "final parameter of a func type",
+			params: []*Param{{Name: "fn", Type: &Type{
+				Kind:   TypeKindFunc,
+				Params: []*Param{{Type: ident("int")}, {Type: variadic("string")}},
+			}}},
+		},
+		{
+			name:   "earlier parameter",
+			params: []*Param{{Name: "args", Type: variadic("string")}, {Name: "n", Type: ident("int")}},
+			err:    "method Do can only have ... as the type of the final parameter",
+		},
+		{
+			name:    "result",
+			results: []*Param{{Type: variadic("string")}},
+			err:     "method Do can only have ... as the type of the final parameter",
+		},
+		{
+			name: "earlier parameter of a func type",
+			results: []*Param{{Type: &Type{
+				Kind:   TypeKindFunc,
+				Params: []*Param{{Type: variadic("string")}, {Type: ident("int")}},
+			}}},
+			err: "method Do can only have ... as the type of the final parameter",
+		},
+		{
+			name:   "slice element",
+			params: []*Param{{Name: "args", Type: &Type{Kind: TypeKindArray, Child: variadic("string")}}},
+			err:    "method Do can only have ... as the type of the final parameter",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			receivers := []Receiver{
+				{Name: "Get", Params: []*Param{{Name: "key", Type: ident("string")}}},
+				{Name: "Do", Params: tc.params, Results: tc.results},
+			}
+
+			// act
+			err := checkVariadic(receivers)
+
+			// assert
+			if tc.err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, tc.err)
+		})
+	}
+}
+
+func TestSubstituteTypeParams(t *testing.T) {
+	const src = `package store
+
+type Cache[K comparable, V any] struct{}
+
+func (c *Cache[K, V]) Swap(key K, values []V) (V, map[K]V) { return *new(V), nil }
+`
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "cache.go", src, 0)
+	require.NoError(t, err)
+
+	receivers, err := ParseReceivers(file, fset, "Cache", "store", nil)
+	require.NoError(t, err)
+
+	// swapped, as in Cache[V, K] of a struct of the same type parameters
+	args := []*Type{
+		{Name: "V", Kind: TypeKindIdent},
+		{Kind: TypeKindArray, Child: &Type{Name: "K", Kind: TypeKindIdent}},
+	}
+
+	// act
+	substituteTypeParams([]method{{Receiver: receivers[0]}}, []string{"K", "V"}, args)
+
+	// assert
+	require.Equal(t, "Swap(key V, values [][]K) ([]K, map[V][]K)", receivers[0].String())
+	require.NotSame(t, args[1].Child, receivers[0].Results[0].Type.Child, "the types are copied")
+}
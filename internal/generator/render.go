@@ -1,18 +1,42 @@
 package generator
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 
 	"golang.org/x/tools/imports"
 )
 
+const sourceHashPrefix = "// source hash: "
+
+// ReadSourceHash returns the SourceHash written to the header
+// of the generated code, it is empty if there is none.
+func ReadSourceHash(code []byte) string {
+	for _, line := range strings.Split(string(code), "\n") {
+		if strings.HasPrefix(line, "package ") {
+			break
+		}
+		if strings.HasPrefix(line, sourceHashPrefix) {
+			return strings.TrimSpace(strings.TrimPrefix(line, sourceHashPrefix))
+		}
+	}
+
+	return ""
+}
+
 func RenderInterface(
-	packageName string,
-	interfaceName string,
-	sourceStructName string,
-	sourcePkgName string,
-	modulePath string,
-	outputFilename string,
+	options Options,
+	interfaceDoc string,
+	typeParams []*Param,
 	receivers []Receiver,
 ) (
 	[]byte,
@@ -20,52 +44,577 @@ func RenderInterface(
 ) {
 	var b strings.Builder
 
+	packageName := options.OutputPackageName
+
+	if options.License != "" {
+		// the blank line keeps the license out of the package doc
+		b.WriteString(licenseHeader(options.License))
+		b.WriteString("\n")
+	}
+
 	// generated comment
 	b.WriteString("// Package ")
 	b.WriteString(packageName)
 	b.WriteString(" generated with github.com/denisdubovitskiy/ifacemaker, DO NOT EDIT.\n")
 
+	if options.Version != "" {
+		b.WriteString("// ifacemaker version: ")
+		b.WriteString(options.Version)
+		b.WriteString("\n")
+	}
+
+	if len(options.Invocation) > 0 {
+		b.WriteString("// invocation: ifacemaker ")
+		b.WriteString(strings.Join(options.Invocation, " "))
+		b.WriteString("\n")
+	}
+
+	if options.SourceHash != "" {
+		b.WriteString(sourceHashPrefix)
+		b.WriteString(options.SourceHash)
+		b.WriteString("\n")
+	}
+
 	// header
 	b.WriteString("package ")
 	b.WriteString(packageName)
 	b.WriteString("\n")
 
+	var imported []Embed
+	for _, e := range options.Embeds {
+		if !options.ownEmbed(e) {
+			imported = append(imported, e)
+		}
+	}
+
+	imports := collectImports(typeParams, receivers, imported)
+	switch {
+	case options.NoImports:
+	default:
+		// goimports can't find the packages the output module doesn't require
+		renderImports(&b, imports, options.GroupImports)
+	}
+
+	if !options.noDirective {
+		renderDirective(&b, options)
+	}
+
+	if options.Prelude != "" {
+		b.WriteString("\n")
+		b.WriteString(strings.TrimRight(options.Prelude, "\n"))
+		b.WriteString("\n\n")
+	}
+
+	for i, iface := range interfacesOf(options, receivers) {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+
+		renderInterface(&b, options, iface, interfaceDoc, typeParams)
+	}
+
+	if options.EmitStub {
+		for _, iface := range interfacesOf(options, receivers) {
+			renderStub(&b, iface, typeParams)
+		}
+	}
+
+	code, err := formatCodeWithGoImports(b.String(), options.NoImports)
+	if err != nil {
+		return nil, err
+	}
+
+	if options.PostProcess != nil {
+		if code, err = postProcess(code, options.PostProcess, options.NoImports); err != nil {
+			return nil, err
+		}
+	}
+
+	if options.IndentSpaces > 0 {
+		code = expandIndent(code, options.IndentSpaces)
+	}
+
+	return code, nil
+}
+
+// renderDirective writes the go:generate directive the file is regenerated
+// with, the one of the FactorCommon interface regenerates the GenerateAll set.
+func renderDirective(b *strings.Builder, options Options) {
 	b.WriteString("//go:generate ifacemaker")
 	b.WriteString(" --source-pkg ")
-	b.WriteString(sourcePkgName)
+	b.WriteString(options.SourcePackage)
 	b.WriteString(" --module-path ")
-	b.WriteString(modulePath)
+	b.WriteString(options.ModulePath)
+	if options.SourceImportPath != "" {
+		b.WriteString(" --source-import-path ")
+		b.WriteString(options.SourceImportPath)
+	}
 	b.WriteString(" --result-pkg ")
-	b.WriteString(packageName)
-	b.WriteString(" --struct-name ")
-	b.WriteString(sourceStructName)
-	b.WriteString(" --interface-name ")
-	b.WriteString(interfaceName)
+	b.WriteString(options.OutputPackageName)
+	switch {
+	case options.factored.common():
+		// the set is regenerated at once, the interfaces depend on each other
+		b.WriteString(" --all-structs --factor-common ")
+		b.WriteString(options.FactorCommon)
+		if options.OutputTemplate == nil && options.FilenameCase != "" && options.FilenameCase != FilenameCaseSnake {
+			b.WriteString(" --filename-case ")
+			b.WriteString(options.FilenameCase)
+		}
+	case len(options.Roles) == 0:
+		b.WriteString(" --struct-name ")
+		b.WriteString(options.StructName)
+		b.WriteString(" --interface-name ")
+		b.WriteString(options.InterfaceName)
+	default:
+		b.WriteString(" --struct-name ")
+		b.WriteString(options.StructName)
+	}
+	if options.FuncType != "" {
+		b.WriteString(" --func-type ")
+		b.WriteString(options.FuncType)
+	}
+	for _, role := range options.Roles {
+		b.WriteString(" --role ")
+		b.WriteString(QuoteArg(role.Name + "=" + role.Filter.String()))
+	}
+	for _, e := range options.Embeds {
+		// the reused one is found again, the FactorCommon
+		// one is declared by the same GenerateAll run
+		if e == options.reused || e.ImportPath == "" {
+			continue
+		}
+		b.WriteString(" --embed ")
+		b.WriteString(e.flag())
+	}
+	if options.EmbedNoDedup {
+		b.WriteString(" --embed-no-dedup")
+	}
+	for _, g := range options.Groups {
+		b.WriteString(" --group-by-prefix ")
+		b.WriteString(QuoteArg(g.Banner + "=" + g.Prefix))
+	}
+	if options.ReuseExistingInterface {
+		b.WriteString(" --reuse-existing-interface")
+	}
+	renamed := make([]string, 0, len(options.Rename))
+	for oldName := range options.Rename {
+		renamed = append(renamed, oldName)
+	}
+	sort.Strings(renamed)
+	for _, oldName := range renamed {
+		b.WriteString(" --rename ")
+		b.WriteString(oldName + "=" + options.Rename[oldName])
+	}
+	if options.StripMethodPrefix != "" {
+		b.WriteString(" --strip-method-prefix ")
+		b.WriteString(options.StripMethodPrefix)
+	}
+	if len(options.Order) > 0 {
+		b.WriteString(" --order ")
+		b.WriteString(strings.Join(options.Order, ","))
+	}
+	if options.AddedSince != "" {
+		b.WriteString(" --added-since ")
+		b.WriteString(options.AddedSince)
+	}
+	if options.Include != nil {
+		b.WriteString(" --include ")
+		b.WriteString(QuoteArg(options.Include.String()))
+	}
+	if options.Exclude != nil {
+		b.WriteString(" --exclude ")
+		b.WriteString(QuoteArg(options.Exclude.String()))
+	}
+	if options.FilterPromoted && (options.Include != nil || options.Exclude != nil) {
+		b.WriteString(" --filter-promoted")
+	}
+	if options.MethodSet == MethodSetValue {
+		b.WriteString(" --method-set value")
+	}
+	if options.IncludeUnexported {
+		b.WriteString(" --include-unexported")
+	}
+	if options.EmitStub {
+		b.WriteString(" --emit-stub")
+	}
+	if options.NoImports {
+		b.WriteString(" --no-imports")
+	}
+	if options.GroupImports {
+		b.WriteString(" --group-imports")
+	}
+	if options.CopyTypeDoc {
+		b.WriteString(" --copy-type-doc")
+	}
+	if options.ReplaceUnexportedWith != "" {
+		b.WriteString(" --replace-unexported-with ")
+		b.WriteString(options.ReplaceUnexportedWith)
+	}
+	if options.UseAny {
+		b.WriteString(" --use-any")
+	}
+	if options.GoVersion != "" {
+		b.WriteString(" --go-version ")
+		b.WriteString(options.GoVersion)
+	}
+	if options.PreserveOrder {
+		b.WriteString(" --preserve-order")
+	}
+	if options.AnnotateSource {
+		b.WriteString(" --annotate-source")
+		if options.TrimPrefix != "" {
+			b.WriteString(" --trim-prefix ")
+			b.WriteString(options.TrimPrefix)
+		}
+	}
+	if options.FlagMissingContext {
+		b.WriteString(" --flag-missing-context")
+	}
+	if options.BestEffort {
+		b.WriteString(" --best-effort")
+	}
+	if options.WidenVariadic {
+		b.WriteString(" --widen-variadic")
+	}
+	if options.CommentWidth > 0 {
+		b.WriteString(" --comment-width ")
+		b.WriteString(strconv.Itoa(options.CommentWidth))
+	}
+	if options.IndentSpaces > 0 {
+		b.WriteString(" --indent-spaces ")
+		b.WriteString(strconv.Itoa(options.IndentSpaces))
+	}
+	if options.Version != "" {
+		b.WriteString(" --header-version")
+	}
+	if options.SourceHash != "" {
+		b.WriteString(" --skip-unchanged")
+	}
+	for _, arg := range options.DirectiveArgs {
+		b.WriteString(" ")
+		b.WriteString(QuoteArg(arg))
+	}
+	// go generate runs the directive in the directory of the file
 	b.WriteString(" --output ")
-	b.WriteString(outputFilename)
+	switch {
+	case options.factored.common():
+		b.WriteString(QuoteArg(setOutput(options)))
+	case options.OutputFilename != "":
+		b.WriteString(filepath.Base(options.OutputFilename))
+	}
 	b.WriteString("\n")
+}
+
+// setOutput is the --output of the GenerateAll set relative to the directory
+// of the FactorCommon file, the directory it is generated in or the template.
+func setOutput(options Options) string {
+	if options.OutputTemplate == nil {
+		return "."
+	}
+
+	tmpl := options.OutputTemplate.Root.String()
+	if filepath.IsAbs(tmpl) {
+		return tmpl
+	}
+
+	dir, err := filepath.Abs(filepath.Dir(options.OutputFilename))
+	if err != nil {
+		return tmpl
+	}
+	workDir, err := filepath.Abs(".")
+	if err != nil {
+		return tmpl
+	}
+	rel, err := filepath.Rel(dir, workDir)
+	if err != nil {
+		return tmpl
+	}
+
+	return filepath.ToSlash(filepath.Join(rel, tmpl))
+}
+
+// postProcess passes the parsed code to the hook and formats what it returns.
+func postProcess(code []byte, hook func(fset *token.FileSet, file *ast.File) error, formatOnly bool) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", code, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := hook(fset, file); err != nil {
+		return nil, fmt.Errorf("post-processing: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, fset, file); err != nil {
+		return nil, fmt.Errorf("printing the post-processed code: %v", err)
+	}
+
+	formatted, err := formatCodeWithGoImports(buf.String(), formatOnly)
+	if err != nil {
+		return nil, fmt.Errorf("the post-processed code is invalid: %v", err)
+	}
+
+	return formatted, nil
+}
+
+// checkPrelude reports whether the prelude is a list of Go declarations,
+// the imports aside.
+func checkPrelude(prelude string) error {
+	if prelude == "" {
+		return nil
+	}
+
+	// on the same line, so the positions match the prelude lines
+	file, err := parser.ParseFile(token.NewFileSet(), "prelude", "package prelude;"+prelude, 0)
+	if err != nil {
+		return fmt.Errorf("parsing the prelude: %v", err)
+	}
+
+	if len(file.Imports) > 0 {
+		return errors.New("the prelude can't import packages, goimports adds the ones it uses")
+	}
+
+	return nil
+}
+
+// licenseHeader comments the license text out line by line, the text which
+// is a comment already is kept as is.
+func licenseHeader(license string) string {
+	license = strings.TrimRight(license, "\n")
+	lines := strings.Split(license, "\n")
+
+	if isComment(lines) {
+		return license + "\n"
+	}
+
+	var b strings.Builder
+
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t")
+		if line == "" {
+			b.WriteString("//\n")
+			continue
+		}
+
+		b.WriteString("// ")
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// isComment reports whether the lines are a block comment
+// or the line comments, the blank lines aside.
+func isComment(lines []string) bool {
+	first := strings.TrimSpace(lines[0])
+	last := strings.TrimSpace(lines[len(lines)-1])
+	if strings.HasPrefix(first, "/*") && strings.HasSuffix(last, "*/") {
+		return true
+	}
+
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "//") {
+			return false
+		}
+	}
+
+	return true
+}
+
+// expandIndent replaces the leading tabs of every line with the
+// given number of spaces. gofmt only uses tabs for the indentation,
+// so the alignment within the lines is kept intact.
+func expandIndent(code []byte, spaces int) []byte {
+	indent := strings.Repeat(" ", spaces)
+	lines := strings.SplitAfter(string(code), "\n")
+
+	for i, line := range lines {
+		trimmed := strings.TrimLeft(line, "\t")
+		lines[i] = strings.Repeat(indent, len(line)-len(trimmed)) + trimmed
+	}
+
+	return []byte(strings.Join(lines, ""))
+}
+
+// QuoteArg quotes an argument of the directive or the header, which go generate
+// or a shell would split otherwise.
+func QuoteArg(arg string) string {
+	if strings.ContainsAny(arg, " \t\"'") {
+		return strconv.Quote(arg)
+	}
+	return arg
+}
+
+type renderedInterface struct {
+	name      string
+	receivers []Receiver
+}
+
+// interfacesOf returns the interfaces to render: one per role, having the
+// methods matching its filter, or a single one with all the methods.
+func interfacesOf(options Options, receivers []Receiver) []renderedInterface {
+	if len(options.Roles) == 0 {
+		return []renderedInterface{{name: options.InterfaceName, receivers: receivers}}
+	}
+
+	interfaces := make([]renderedInterface, len(options.Roles))
+
+	for i, role := range options.Roles {
+		interfaces[i].name = role.Name
+
+		for _, r := range receivers {
+			if role.Filter.MatchString(r.Name) {
+				interfaces[i].receivers = append(interfaces[i].receivers, r)
+			}
+		}
+	}
+
+	return interfaces
+}
+
+type receiverGroup struct {
+	banner    string
+	receivers []Receiver
+}
+
+// groupReceivers splits the receivers by the longest group prefix of their
+// names keeping their order. The group of no banner comes first, then the
+// others ordered by the banner, the empty ones are dropped.
+func groupReceivers(groups []Group, receivers []Receiver) []receiverGroup {
+	if len(groups) == 0 {
+		return []receiverGroup{{receivers: receivers}}
+	}
+
+	byBanner := make(map[string][]Receiver)
+
+	for _, r := range receivers {
+		var match *Group
+		for i, g := range groups {
+			if strings.HasPrefix(r.Name, g.Prefix) && (match == nil || len(g.Prefix) > len(match.Prefix)) {
+				match = &groups[i]
+			}
+		}
+
+		var banner string
+		if match != nil {
+			banner = match.Banner
+		}
+		byBanner[banner] = append(byBanner[banner], r)
+	}
+
+	banners := make([]string, 0, len(byBanner))
+	for banner := range byBanner {
+		banners = append(banners, banner)
+	}
+	sort.Strings(banners)
+
+	result := make([]receiverGroup, len(banners))
+	for i, banner := range banners {
+		result[i] = receiverGroup{banner: banner, receivers: byBanner[banner]}
+	}
+
+	return result
+}
+
+func renderInterface(b *strings.Builder, options Options, iface renderedInterface, doc string, typeParams []*Param) {
+	// the common interface has no source struct
+	if options.AnnotateSource && !options.factored.common() {
+		if doc != "" {
+			doc += "//\n"
+		}
+		doc += sourceAnnotation(options, iface.name)
+	}
 
 	// interface header
+	if doc != "" {
+		// keep the doc apart from the go:generate directive,
+		// otherwise gofmt merges them into a single comment
+		b.WriteString("\n")
+		b.WriteString(doc)
+	}
 	b.WriteString("type ")
-	b.WriteString(interfaceName)
+	b.WriteString(iface.name)
+	if len(typeParams) > 0 {
+		params := make([]string, len(typeParams))
+		for i, p := range typeParams {
+			params[i] = p.String()
+		}
+
+		b.WriteString("[")
+		b.WriteString(strings.Join(params, ", "))
+		b.WriteString("]")
+	}
+
+	if options.FuncType != "" {
+		// the method itself is the only one left
+		b.WriteString(" func")
+		writeSignature(b, iface.receivers[0].Params, iface.receivers[0].Results)
+		b.WriteString("\n")
+		return
+	}
+
 	b.WriteString(" interface {\n")
 
-	for _, receiver := range receivers {
-		b.WriteString(receiver.String())
+	for _, e := range options.Embeds {
+		if options.ownEmbed(e) {
+			b.WriteString(e.Name)
+		} else {
+			b.WriteString(e.String())
+		}
+		b.WriteString("\n")
+	}
+	if len(options.Embeds) > 0 && len(iface.receivers) > 0 {
 		b.WriteString("\n")
 	}
 
+	for i, group := range groupReceivers(options.Groups, iface.receivers) {
+		if group.banner != "" {
+			if i > 0 {
+				b.WriteString("\n")
+			}
+			b.WriteString("// ")
+			b.WriteString(group.banner)
+			b.WriteString("\n")
+
+			// keep the banner apart from the doc of the first method
+			if group.receivers[0].Comment != "" {
+				b.WriteString("\n")
+			}
+		}
+
+		for _, receiver := range group.receivers {
+			b.WriteString(receiver.String())
+			b.WriteString("\n")
+		}
+	}
+
 	// interface footer
 	b.WriteString("}\n")
+}
+
+// sourceAnnotation is a comment line telling where the methods come from.
+// The prefix is only trimmed there for readability, the imports keep the
+// full paths.
+func sourceAnnotation(options Options, interfaceName string) string {
+	source := sourceImportPath(options)
+	if options.TrimPrefix != "" {
+		source = strings.TrimPrefix(strings.TrimPrefix(source, options.TrimPrefix), "/")
+	}
 
-	return formatCodeWithGoImports(b.String())
+	return "// " + interfaceName + " is generated from " + source + "." + options.StructName + ".\n"
 }
 
-func formatCodeWithGoImports(code string) ([]byte, error) {
+// formatCodeWithGoImports formats the code and fixes its imports,
+// the imports are kept as they are with formatOnly.
+func formatCodeWithGoImports(code string, formatOnly bool) ([]byte, error) {
 	return imports.Process("", []byte(code), &imports.Options{
-		TabIndent: true,
-		TabWidth:  4,
-		Fragment:  true,
-		Comments:  true,
+		TabIndent:  true,
+		TabWidth:   4,
+		Fragment:   true,
+		Comments:   true,
+		FormatOnly: formatOnly,
 	})
 }
@@ -0,0 +1,33 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuoteArg(t *testing.T) {
+	cases := []struct {
+		arg  string
+		want string
+	}{
+		{arg: "--struct-name", want: "--struct-name"},
+		{arg: "^Get.*$", want: "^Get.*$"},
+		{arg: "Public API=Get", want: `"Public API=Get"`},
+		{arg: "a\tb", want: `"a\tb"`},
+		{arg: `say "hi"`, want: `"say \"hi\""`},
+		{arg: "it's", want: `"it's"`},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.arg, func(t *testing.T) {
+			// act
+			got := QuoteArg(tc.arg)
+
+			// assert
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
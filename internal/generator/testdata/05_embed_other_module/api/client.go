@@ -0,0 +1,14 @@
+package api
+
+import "example.com/store"
+
+type Client struct {
+	*store.Cache
+
+	name string
+}
+
+// Name shadows the promoted Cache.Name.
+func (c *Client) Name() string {
+	return c.name
+}
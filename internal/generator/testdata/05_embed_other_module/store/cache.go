@@ -0,0 +1,25 @@
+package store
+
+type Item struct {
+	Key string
+}
+
+type Backend interface {
+	Flush() error
+}
+
+type Cache struct {
+	Backend
+
+	items map[string]*Item
+}
+
+// Get returns a cached item.
+func (c *Cache) Get(key string) (*Item, bool) {
+	item, ok := c.items[key]
+	return item, ok
+}
+
+func (c *Cache) Name() string {
+	return "cache"
+}
@@ -0,0 +1,7 @@
+package api
+
+type Client struct{}
+
+func (c *Client) Ping() error {
+	return nil
+}
@@ -0,0 +1,21 @@
+package api
+
+type Client struct{}
+
+type Request struct{}
+
+type options struct{}
+
+type requestError struct{}
+
+func (e requestError) Error() string {
+	return "request failed"
+}
+
+func (c *Client) Do(req *Request, opts *options) *requestError {
+	return nil
+}
+
+func (c *Client) Batch(reqs []Request, opts []options) map[string]requestError {
+	return nil
+}
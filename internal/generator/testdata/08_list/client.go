@@ -0,0 +1,17 @@
+package api
+
+type Client struct{}
+
+type Option func(*Client)
+
+type Doer interface {
+	Do() error
+}
+
+type request struct{}
+
+func (c *Client) Do() error {
+	return nil
+}
+
+func (c *Client) close() {}
@@ -0,0 +1,13 @@
+package api
+
+type Response struct{}
+
+func (c Client) Ping() error {
+	return nil
+}
+
+func (r *Response) Body() []byte {
+	return nil
+}
+
+func (r *request) Send() {}
@@ -0,0 +1,7 @@
+package api
+
+type Client struct{}
+
+func (c *Client) Get(id string) (*User, error) {
+	return nil, nil
+}
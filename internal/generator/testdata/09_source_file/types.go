@@ -0,0 +1,8 @@
+package api
+
+type User struct{}
+
+// Delete is declared in a sibling file and is not collected.
+func (c *Client) Delete(id string) error {
+	return nil
+}
@@ -0,0 +1,29 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	yaml "gopkg.in/yaml.v2"
+	zaplog "go.uber.org/zap"
+	"io"
+)
+
+type Client struct{}
+
+func (c *Client) Do(ctx context.Context, req *http.Request) (*http.Response, error) {
+	return nil, nil
+}
+
+func (c *Client) Decode(r io.Reader, out yaml.MapSlice) error {
+	return nil
+}
+
+func (c *Client) Logger() *zaplog.Logger {
+	return nil
+}
+
+func (c *Client) Session() uuid.UUID {
+	return uuid.UUID{}
+}
@@ -0,0 +1,17 @@
+package client
+
+import "context"
+
+type Client struct{}
+
+// Get returns a value.
+//
+// Example:
+//	v, err := c.Get(ctx, "key")
+func (c *Client) Get(ctx context.Context, key string) (string, error) {
+	return "", nil
+}
+
+func (c *Client) Close() error {
+	return nil
+}
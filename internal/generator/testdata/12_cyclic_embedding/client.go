@@ -0,0 +1,17 @@
+package client
+
+type Client struct {
+	*Transport
+}
+
+func (c *Client) Get(key string) (string, error) {
+	return "", nil
+}
+
+type Transport struct {
+	*Client
+}
+
+func (t *Transport) RoundTrip(req []byte) ([]byte, error) {
+	return nil, nil
+}
@@ -0,0 +1,29 @@
+package client
+
+// Error shadows the name of the builtin interface method.
+type Error struct {
+	Code int
+}
+
+// String is not the builtin string.
+type String string
+
+func (e Error) Error() string {
+	return ""
+}
+
+type Client struct {
+	Error
+}
+
+func (c *Client) Do(name String) (*Error, error) {
+	return nil, nil
+}
+
+func (c *Client) Last() Error {
+	return Error{}
+}
+
+func (c *Client) Names() map[String][]string {
+	return nil
+}
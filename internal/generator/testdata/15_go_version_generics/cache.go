@@ -0,0 +1,9 @@
+package cache
+
+type Cache[K comparable, V any] struct {
+	items map[K]V
+}
+
+func (c *Cache[K, V]) Len() int {
+	return len(c.items)
+}
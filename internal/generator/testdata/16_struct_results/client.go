@@ -0,0 +1,20 @@
+package client
+
+type Status int
+
+type Client struct{}
+
+func (c *Client) Close() struct{} {
+	return struct{}{}
+}
+
+func (c *Client) Ping() struct{ OK bool } {
+	return struct{ OK bool }{OK: true}
+}
+
+func (c *Client) Stat() (s struct {
+	Code, Retries int
+	Status        Status `json:"status"`
+}, err error) {
+	return
+}
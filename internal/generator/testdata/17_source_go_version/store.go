@@ -0,0 +1,24 @@
+package store
+
+type Set[T comparable] map[T]struct{}
+
+func Keys[K comparable, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+type Store struct {
+	items map[string]any
+}
+
+func (s *Store) Get(key string) (any, bool) {
+	v, ok := s.items[key]
+	return v, ok
+}
+
+func (s *Store) Keys() []string {
+	return Keys(s.items)
+}
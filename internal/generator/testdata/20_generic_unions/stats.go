@@ -0,0 +1,28 @@
+package stats
+
+import "time"
+
+type Number interface {
+	~int | ~int64 | ~float64
+}
+
+type Set[T comparable] map[T]struct{}
+
+type Stats[K int | string, D ~int32 | time.Duration, N Number] struct {
+	values map[K]N
+}
+
+func (s *Stats[K, D, N]) Add(key K, value N) {}
+
+func (s *Stats[K, D, N]) Window() D {
+	var d D
+	return d
+}
+
+func (s *Stats[K, D, N]) Keys() Set[K] {
+	return nil
+}
+
+func (s *Stats[K, D, N]) Index() map[K]Set[D] {
+	return nil
+}
@@ -0,0 +1,11 @@
+package store
+
+type Store struct{}
+
+func (s *Store) Get(key string) string {
+	return ""
+}
+
+func (s *Store) Has(key string) bool {
+	return false
+}
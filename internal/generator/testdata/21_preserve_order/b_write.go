@@ -0,0 +1,5 @@
+package store
+
+func (s *Store) Set(key, value string) {}
+
+func (s *Store) Delete(key string) {}
@@ -0,0 +1,11 @@
+package api
+
+import "context"
+
+type Request struct{}
+
+type Client struct{}
+
+func (c *Client) Do(ctx context.Context, req *Request) error {
+	return nil
+}
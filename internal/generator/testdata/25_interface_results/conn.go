@@ -0,0 +1,24 @@
+package conn
+
+import (
+	"context"
+	"io"
+)
+
+type Conn struct{}
+
+func (c *Conn) Context() context.Context {
+	return context.Background()
+}
+
+func (c *Conn) Body() io.Closer {
+	return nil
+}
+
+func (c *Conn) Both() (context.Context, io.ReadCloser) {
+	return nil, nil
+}
+
+func (c *Conn) Any() interface{} {
+	return nil
+}
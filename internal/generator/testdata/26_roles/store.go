@@ -0,0 +1,15 @@
+package store
+
+type Store struct{}
+
+func (s *Store) Get(key string) string {
+	return ""
+}
+
+func (s *Store) Has(key string) bool {
+	return false
+}
+
+func (s *Store) Set(key, value string) {}
+
+func (s *Store) Delete(key string) {}
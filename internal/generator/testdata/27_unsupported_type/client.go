@@ -0,0 +1,13 @@
+package client
+
+const Size = 8
+
+type Client struct{}
+
+func (c *Client) Get(key string) (string, error) {
+	return "", nil
+}
+
+func (c *Client) Set(key string, value [2 * Size]byte) error {
+	return nil
+}
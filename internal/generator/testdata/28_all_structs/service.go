@@ -0,0 +1,27 @@
+package service
+
+type Client struct{}
+
+func (c *Client) Get(key string) (string, error) {
+	return "", nil
+}
+
+func (c *Client) Ping() error {
+	return nil
+}
+
+type Health struct{}
+
+func (h *Health) Ping() error {
+	return nil
+}
+
+type Store struct{}
+
+func (s *Store) Load(key string) ([]byte, error) {
+	return nil, nil
+}
+
+func (s *Store) Save(key string, value []byte) error {
+	return nil
+}
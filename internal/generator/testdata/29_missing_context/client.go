@@ -0,0 +1,25 @@
+package client
+
+import (
+	"context"
+)
+
+type Client struct{}
+
+// Get returns the value by the key.
+func (c *Client) Get(ctx context.Context, key string) (string, error) {
+	return "", nil
+}
+
+// Set stores the value.
+func (c *Client) Set(key string, value string) error {
+	return nil
+}
+
+func (c *Client) Delete(key string, ctx context.Context) error {
+	return nil
+}
+
+func (c *Client) Close() error {
+	return nil
+}
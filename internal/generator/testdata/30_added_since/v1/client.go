@@ -0,0 +1,19 @@
+package client
+
+import (
+	"context"
+)
+
+type Client struct{}
+
+func (c *Client) Get(ctx context.Context, key string) (string, error) {
+	return "", nil
+}
+
+func (c *Client) Set(key, value string) error {
+	return nil
+}
+
+func (c *Client) Close() error {
+	return nil
+}
@@ -0,0 +1,27 @@
+package client
+
+import (
+	"context"
+	"time"
+)
+
+type Client struct{}
+
+// Get is not changed, but the parameter is renamed.
+func (c *Client) Get(ctx context.Context, name string) (string, error) {
+	return "", nil
+}
+
+// Set got a context.
+func (c *Client) Set(ctx context.Context, key, value string) error {
+	return nil
+}
+
+// SetTTL is a new one.
+func (c *Client) SetTTL(ctx context.Context, key, value string, ttl time.Duration) error {
+	return nil
+}
+
+func (c *Client) Close() error {
+	return nil
+}
@@ -0,0 +1,27 @@
+package client
+
+type Client struct{}
+
+func (c *Client) Get(key string) (string, error) {
+	return "", nil
+}
+
+func (*Client) Ping() error {
+	return nil
+}
+
+func (Client) Name() string {
+	return "client"
+}
+
+func (_ *Client) Close() error {
+	return nil
+}
+
+func (_ (*Client)) Reset() {}
+
+func (*Other) Stop() error {
+	return nil
+}
+
+type Other struct{}
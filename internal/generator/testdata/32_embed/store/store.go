@@ -0,0 +1,11 @@
+package store
+
+// Getter gets the values.
+type Getter interface {
+	Get(key string) (string, error)
+}
+
+// Closer is closed after use.
+type Closer interface {
+	Close() error
+}
@@ -0,0 +1,11 @@
+package client
+
+type Client struct{}
+
+func (c *Client) Get(key string) (string, error) {
+	return "", nil
+}
+
+func (c *Client) Set(key string, value string) error {
+	return nil
+}
@@ -0,0 +1,20 @@
+package client
+
+import (
+	"github.com/acme/pkg"
+)
+
+type Result[T any] struct {
+	Value T
+	Err   error
+}
+
+type Client struct{}
+
+func (c *Client) Fetch(id string) Result[pkg.Thing] {
+	return Result[pkg.Thing]{}
+}
+
+func (c *Client) FetchAll(ids []string) Result[map[string]*pkg.Thing] {
+	return Result[map[string]*pkg.Thing]{}
+}
@@ -0,0 +1,21 @@
+package store
+
+import (
+	"context"
+)
+
+// Closer is closed after use.
+type Closer interface {
+	Close() error
+}
+
+// Terminator is stopped or closed.
+type Terminator interface {
+	Stop()
+	Close() error
+}
+
+// Shutdowner is closed within a deadline.
+type Shutdowner interface {
+	Close(ctx context.Context) error
+}
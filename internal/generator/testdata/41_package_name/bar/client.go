@@ -0,0 +1,13 @@
+package foo
+
+type Item struct{}
+
+type Client struct{}
+
+func (c *Client) Get(key string) (*Item, error) {
+	return nil, nil
+}
+
+func (c *Client) List() []Item {
+	return nil
+}
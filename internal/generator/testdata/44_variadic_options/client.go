@@ -0,0 +1,31 @@
+package client
+
+import (
+	"time"
+)
+
+type Option func(*options)
+
+type options struct {
+	timeout time.Duration
+}
+
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) { o.timeout = timeout }
+}
+
+type Client struct{}
+
+func New(opts ...Option) *Client {
+	return &Client{}
+}
+
+func (c *Client) Get(key string, opts ...Option) (string, error) {
+	return "", nil
+}
+
+func (c *Client) Configure(opts ...Option) {}
+
+func (c *Client) Options() []Option {
+	return nil
+}
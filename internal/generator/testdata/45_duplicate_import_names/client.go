@@ -0,0 +1,14 @@
+package client
+
+import (
+	v1 "github.com/acme/api/v1"
+	"github.com/acme/store"
+)
+
+type Client struct {
+	*store.Store
+}
+
+func (c *Client) Get(name string) (*v1.Object, error) {
+	return nil, nil
+}
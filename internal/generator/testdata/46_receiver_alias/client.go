@@ -0,0 +1,20 @@
+package client
+
+type Client struct{}
+
+type (
+	C       = Client
+	Default = C
+)
+
+func (c *Client) Get(key string) (string, error) {
+	return "", nil
+}
+
+func (c C) Set(key string, value string) error {
+	return nil
+}
+
+func (c *Default) Close() error {
+	return nil
+}
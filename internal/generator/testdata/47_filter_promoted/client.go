@@ -0,0 +1,19 @@
+package client
+
+type Conn interface {
+	Read(p []byte) (int, error)
+	Write(p []byte) (int, error)
+	Close() error
+}
+
+type Client struct {
+	Conn
+}
+
+func (c *Client) Get(key string) (string, error) {
+	return "", nil
+}
+
+func (c *Client) Set(key string, value string) error {
+	return nil
+}
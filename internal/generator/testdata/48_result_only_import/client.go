@@ -0,0 +1,22 @@
+package client
+
+import (
+	"time"
+
+	"github.com/acme/model/v2"
+	"github.com/acme/store"
+)
+
+type Client struct{}
+
+func (c *Client) Users(limit int) (map[string][]*model.User, error) {
+	return nil, nil
+}
+
+func (c *Client) Iterate() func() (store.Cursor, bool) {
+	return nil
+}
+
+func (c *Client) Ticks() <-chan time.Time {
+	return nil
+}
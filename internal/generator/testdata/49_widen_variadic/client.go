@@ -0,0 +1,17 @@
+package client
+
+type Option func(*Client)
+
+type Client struct{}
+
+func (c *Client) Get(key string, opts ...Option) (string, error) {
+	return "", nil
+}
+
+func (c *Client) Each(fn func(keys ...string) error, keys ...string) error {
+	return nil
+}
+
+func (c *Client) Keys(prefix string) ([]string, error) {
+	return nil, nil
+}
@@ -0,0 +1,31 @@
+package client
+
+import "context"
+
+type Client struct{}
+
+// Put stores the value under the key.
+//
+// An existing value is replaced.
+func (c *Client) Put(
+	ctx context.Context, // cancels the request
+	key string,
+	value []byte, // stored as is
+) (
+	version int64, // of the stored value
+	err error,
+) {
+	return 0, nil
+}
+
+// Delete removes the key.
+func (c *Client) Delete(ctx context.Context,
+	key string) error {
+	return nil
+}
+
+func (c *Client) Len(
+	ctx context.Context,
+) (int, error) {
+	return 0, nil
+}
@@ -0,0 +1,18 @@
+package lib
+
+import (
+	"github.com/go-redis/redis/v8"
+	"gopkg.in/yaml.v3"
+)
+
+type Options struct{}
+
+type Client struct{}
+
+func (c *Client) Conn() *redis.Client {
+	return nil
+}
+
+func (c *Client) Config(node *yaml.Node) (*Options, error) {
+	return nil, nil
+}
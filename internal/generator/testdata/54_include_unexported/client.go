@@ -0,0 +1,23 @@
+package client
+
+import "net/http"
+
+type options struct{}
+
+type Item struct{}
+
+type Client struct {
+	httpClient *http.Client
+}
+
+func (c *Client) Get(key string) (*Item, error) {
+	return c.get(key, options{})
+}
+
+func (c *Client) get(key string, opts options) (*Item, error) {
+	return nil, nil
+}
+
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	return c.httpClient.Do(req)
+}
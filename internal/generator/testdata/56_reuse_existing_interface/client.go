@@ -0,0 +1,34 @@
+package client
+
+import "context"
+
+type Item struct{}
+
+// Getter is implemented by Client, but Clienter has more methods.
+type Getter interface {
+	Get(ctx context.Context, key string) (*Item, error)
+}
+
+type Clienter interface {
+	Getter
+	Delete(ctx context.Context, key string) error
+}
+
+// Setter has a method of another signature.
+type Setter interface {
+	Set(key string, item *Item) error
+}
+
+type Client struct{}
+
+func (c *Client) Get(ctx context.Context, key string) (*Item, error) {
+	return nil, nil
+}
+
+func (c *Client) Set(ctx context.Context, key string, item *Item) error {
+	return nil
+}
+
+func (c *Client) Delete(ctx context.Context, key string) error {
+	return nil
+}
@@ -0,0 +1,26 @@
+package client
+
+type Client struct{}
+
+func (c *Client) Close() error {
+	return nil
+}
+
+// SetItem stores the item.
+func (c *Client) SetItem(key, value string) error {
+	return nil
+}
+
+func (c *Client) GetItem(key string) (string, error) {
+	return "", nil
+}
+
+func (c *Client) GetAllItems() ([]string, error) {
+	return nil, nil
+}
+
+func (c *Client) GetCount() int {
+	return 0
+}
+
+func (c *Client) Ping() {}
@@ -0,0 +1,16 @@
+package client
+
+import "context"
+
+type Item struct{}
+
+type Client struct{}
+
+// Get returns the items by the keys.
+func (c *Client) Get(ctx context.Context, keys ...string) (items []*Item, err error) {
+	return nil, nil
+}
+
+func (c *Client) Close() error {
+	return nil
+}
@@ -0,0 +1,16 @@
+package client
+
+import (
+	"github.com/acme/lib/v2"
+	libv1 "github.com/acme/lib"
+)
+
+type Client struct{}
+
+func (c *Client) Get(id lib.ID) (*lib.Item, error) {
+	return nil, nil
+}
+
+func (c *Client) GetLegacy(id libv1.ID) (*libv1.Item, error) {
+	return nil, nil
+}
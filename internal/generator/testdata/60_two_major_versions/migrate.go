@@ -0,0 +1,11 @@
+package client
+
+import (
+	"github.com/acme/lib"
+	libv2 "github.com/acme/lib/v2"
+)
+
+// Migrate converts the item of the first version.
+func (c *Client) Migrate(item *lib.Item) (*libv2.Item, error) {
+	return nil, nil
+}
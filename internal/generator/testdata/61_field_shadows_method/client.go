@@ -0,0 +1,32 @@
+package client
+
+type Logger struct{}
+
+func (l *Logger) Log(message string) {}
+
+type Base struct{}
+
+// Logger is shadowed by the embedded *Logger field of Client.
+func (b *Base) Logger() *Logger {
+	return nil
+}
+
+func (b *Base) Close() error {
+	return nil
+}
+
+// Timeout is shadowed by the Timeout field of Client.
+func (b *Base) Timeout() int {
+	return 0
+}
+
+type Client struct {
+	Base
+	*Logger
+
+	Timeout int
+}
+
+func (c *Client) Get(key string) (string, error) {
+	return "", nil
+}
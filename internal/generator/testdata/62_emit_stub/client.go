@@ -0,0 +1,60 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"github.com/acme/store"
+)
+
+type Item struct{}
+
+type Status string
+
+type Code uint8
+
+type Flag bool
+
+type Level Code
+
+type Tags []string
+
+type Index map[string]int
+
+type Handler func(ctx context.Context) error
+
+type Events chan Item
+
+type Getter interface {
+	Get(key string) (*Item, error)
+}
+
+type Digest [32]byte
+
+type Client struct{}
+
+func (c *Client) Close() {}
+
+func (c *Client) Get(ctx context.Context, key string) (*Item, error) {
+	return nil, nil
+}
+
+func (c *Client) Basic() (bool, string, int, uint64, float64, complex128, byte, rune, error) {
+	return false, "", 0, 0, 0, 0, 0, 0, nil
+}
+
+func (c *Client) Composite() ([]int, map[string]int, func(), chan int, interface{}, struct{ A int }) {
+	return nil, nil, nil, nil, nil, struct{ A int }{}
+}
+
+func (c *Client) Named() (Item, Status, Code, Flag, Level, Tags, Index, Handler, Events, Getter, Digest) {
+	return Item{}, "", 0, false, 0, nil, nil, nil, nil, nil, Digest{}
+}
+
+func (c *Client) Imported() (store.Version, store.Record, time.Duration) {
+	return 0, store.Record{}, 0
+}
+
+func (c *Client) NamedResults(key string) (n int, err error) {
+	return 0, nil
+}
@@ -0,0 +1,7 @@
+package store
+
+type Version int
+
+type Record struct {
+	Version Version
+}
@@ -0,0 +1,16 @@
+package client
+
+import (
+	"context"
+	"time"
+)
+
+type Client struct{}
+
+func (c *Client) Do(time int) time.Duration {
+	return 0
+}
+
+func (c *Client) Wait(context context.Context, context1 int) (time time.Time, err error) {
+	return
+}
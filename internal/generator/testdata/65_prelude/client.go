@@ -0,0 +1,17 @@
+package api
+
+import "context"
+
+type Client struct{}
+
+type options struct{}
+
+// Do sends the request.
+func (c *Client) Do(ctx context.Context, opts *options) error {
+	return nil
+}
+
+// Batch sends the requests.
+func (c *Client) Batch(ctx context.Context, opts []options) (map[string]options, error) {
+	return nil, nil
+}
@@ -0,0 +1,19 @@
+package api
+
+import "context"
+
+type Client struct{}
+
+type Request struct{}
+
+type Response struct{}
+
+// Do sends the request, the types are qualified with the package itself.
+func (c *Client) Do(ctx context.Context, req *api.Request) (*api.Response, error) {
+	return nil, nil
+}
+
+// Batch sends the requests.
+func (c *Client) Batch(ctx context.Context, reqs []Request) (map[string]api.Response, error) {
+	return nil, nil
+}
@@ -0,0 +1,28 @@
+package api
+
+import "context"
+
+type Client struct{}
+
+// Get returns the item.
+func (c *Client) Get(ctx context.Context, key string) (string, error) {
+	return "", nil
+}
+
+// Fetch returns the items.
+//
+//ifacemaker:rename List
+func (c *Client) Fetch(ctx context.Context) ([]string, error) {
+	return nil, nil
+}
+
+// Close releases the connections, the callers don't have to.
+//ifacemaker:skip
+func (c *Client) Close() error {
+	return nil
+}
+
+//ifacemaker:rename Remove
+func (c *Client) Delete(ctx context.Context, key string) error {
+	return nil
+}
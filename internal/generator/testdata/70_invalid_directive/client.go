@@ -0,0 +1,9 @@
+package api
+
+type Client struct{}
+
+// Close releases the connections.
+//ifacemaker:rename
+func (c *Client) Close() error {
+	return nil
+}
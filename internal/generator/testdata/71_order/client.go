@@ -0,0 +1,27 @@
+package api
+
+import "context"
+
+type Client struct{}
+
+func (c *Client) Delete(ctx context.Context, key string) error {
+	return nil
+}
+
+// Set stores the value.
+func (c *Client) Set(ctx context.Context, key, value string) error {
+	return nil
+}
+
+func (c *Client) Close() error {
+	return nil
+}
+
+// Get returns the value.
+func (c *Client) Get(ctx context.Context, key string) (string, error) {
+	return "", nil
+}
+
+func (c *Client) Keys(ctx context.Context) ([]string, error) {
+	return nil, nil
+}
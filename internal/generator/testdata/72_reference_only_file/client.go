@@ -0,0 +1,12 @@
+package api
+
+import "context"
+
+type Client struct{}
+
+type Item struct{}
+
+// Get returns the item.
+func (c *Client) Get(ctx context.Context, key string) (*Item, error) {
+	return nil, nil
+}
@@ -0,0 +1,24 @@
+package api_test
+
+import (
+	"context"
+
+	apipkg "github.com/acme/api"
+)
+
+// Client is the client the tests use.
+type Client = apipkg.Client
+
+type fakeClient struct {
+	*apipkg.Client
+}
+
+func (f *fakeClient) Get(ctx context.Context, key string) (*apipkg.Item, error) {
+	return &apipkg.Item{}, nil
+}
+
+func (f *fakeClient) Reset() {}
+
+func newClient() *Client {
+	return &apipkg.Client{}
+}
@@ -0,0 +1,35 @@
+package api
+
+import "context"
+
+// Client talks to the storage service over a pooled connection, it is safe for the concurrent use.
+type Client struct{}
+
+// Get returns the value stored under the key. The value is looked up in the local cache first and in the storage next,
+// the misses are not cached.
+//
+// Usage:
+//
+//	value, err := client.Get(ctx, "a key which is long enough to go past the width of the comment")
+//	if err != nil {
+//		return err
+//	}
+//
+// The errors are:
+//   - ErrNotFound if there is no value under the key, which is the case for the expired ones as well
+//   - ErrClosed if the client is closed
+func (c *Client) Get(ctx context.Context, key string) (string, error) {
+	return "", nil
+}
+
+// Fetch returns the value.
+//
+// Deprecated: use Get, which looks the value up in the local cache first and doesn't go to the storage.
+func (c *Client) Fetch(ctx context.Context, key string) (string, error) {
+	return "", nil
+}
+
+// Close releases the connections.
+func (c *Client) Close() error {
+	return nil
+}
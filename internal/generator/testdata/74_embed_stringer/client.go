@@ -0,0 +1,17 @@
+package api
+
+import "context"
+
+type Client struct {
+	addr string
+}
+
+// Get returns the value.
+func (c *Client) Get(ctx context.Context, key string) (string, error) {
+	return "", nil
+}
+
+// String returns the address of the client.
+func (c *Client) String() string {
+	return c.addr
+}
@@ -0,0 +1,7 @@
+package fmt
+
+// Stringer is implemented by any value that has a String method,
+// which defines the native format for that value.
+type Stringer interface {
+	String() string
+}
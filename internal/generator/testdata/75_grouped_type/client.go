@@ -0,0 +1,35 @@
+package store
+
+import "context"
+
+// The types of the storage client.
+type (
+	// Options configure a Client.
+	Options struct {
+		Addr string
+	}
+
+	// Client talks to the storage service.
+	Client struct {
+		opts Options
+	}
+
+	Pool struct {
+		clients []*Client
+	}
+)
+
+// Get returns the value stored under the key.
+func (c *Client) Get(ctx context.Context, key string) (string, error) {
+	return "", nil
+}
+
+// Options returns the options the client is created with.
+func (c *Client) Options() Options {
+	return c.opts
+}
+
+// Acquire takes a client from the pool.
+func (p *Pool) Acquire() *Client {
+	return p.clients[0]
+}
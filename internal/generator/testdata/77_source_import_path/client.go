@@ -0,0 +1,14 @@
+package client // import "go.acme.dev/store/client"
+
+import "context"
+
+type Item struct {
+	Key string
+}
+
+type Client struct{}
+
+// Get returns the item stored under the key.
+func (c *Client) Get(ctx context.Context, key string) (*Item, error) {
+	return nil, nil
+}
@@ -0,0 +1,9 @@
+package api
+
+type Client struct{}
+
+// Get decodes the value.
+func (c *Client) Get[T any](key string) (T, error) {
+	var v T
+	return v, nil
+}
@@ -0,0 +1,45 @@
+package service
+
+import "context"
+
+type Cache struct{}
+
+// Ping checks the cache is reachable.
+func (c *Cache) Ping(ctx context.Context) error { return nil }
+
+// Close releases the cache.
+func (c *Cache) Close() error { return nil }
+
+// Stats returns the hit rate.
+func (c *Cache) Stats() float64 { return 0 }
+
+type Client struct{}
+
+// Ping checks the server is reachable.
+func (c *Client) Ping(ctx context.Context) error { return nil }
+
+// Close closes the connection.
+func (c *Client) Close() error { return nil }
+
+// Name returns the name of the client.
+func (c *Client) Name() string { return "" }
+
+// Stats returns the number of the requests.
+func (c *Client) Stats() int { return 0 }
+
+// Get returns the value of the key.
+func (c *Client) Get(ctx context.Context, key string) (string, error) { return "", nil }
+
+type Store struct{}
+
+// Ping checks the storage is reachable.
+func (s *Store) Ping(c context.Context) error { return nil }
+
+// Close flushes the store.
+func (s *Store) Close() error { return nil }
+
+// Name returns the name of the store.
+func (s *Store) Name() string { return "" }
+
+// Put stores the value under the key.
+func (s *Store) Put(ctx context.Context, key, value string) error { return nil }
@@ -0,0 +1,19 @@
+package text
+
+// rune is a character along with its width.
+type rune struct {
+	r     int32
+	width int
+}
+
+type Reader struct{}
+
+// Next returns the next character of the text.
+func (r *Reader) Next() (rune, error) {
+	return rune{}, nil
+}
+
+// Read reads the text into the buffer.
+func (r *Reader) Read(p []byte) (int, error) {
+	return 0, nil
+}
@@ -0,0 +1,32 @@
+package api
+
+import "context"
+
+// Client talks to the API.
+type Client struct {
+	Doer
+}
+
+// Doer sends the requests.
+type Doer struct{}
+
+// Do sends the request.
+func (d *Doer) Do(ctx context.Context) error {
+	return nil
+}
+
+// Get returns the item.
+func (c *Client) Get(ctx context.Context, id string) (*Item, error) {
+	return nil, nil
+}
+
+type Item struct{}
+
+// AdminClient talks to the admin API, it has the fields of Client but
+// none of its methods.
+type AdminClient Client
+
+// Ban bans the user.
+func (c *AdminClient) Ban(ctx context.Context, user string) error {
+	return nil
+}
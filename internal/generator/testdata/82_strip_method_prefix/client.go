@@ -0,0 +1,34 @@
+package api
+
+import "context"
+
+type Client struct{}
+
+// APIGetUser returns the user.
+func (c *Client) APIGetUser(ctx context.Context, id string) (*User, error) {
+	return nil, nil
+}
+
+// APIDeleteUser deletes the user.
+func (c *Client) APIDeleteUser(ctx context.Context, id string) error {
+	return nil
+}
+
+// APIListUsers lists the users.
+//
+//ifacemaker:rename Users
+func (c *Client) APIListUsers(ctx context.Context) ([]*User, error) {
+	return nil, nil
+}
+
+// API returns the version of the API.
+func (c *Client) API() string {
+	return ""
+}
+
+// APIs returns the names of the APIs.
+func (c *Client) APIs() []string {
+	return nil
+}
+
+type User struct{}
@@ -0,0 +1,17 @@
+package api
+
+import "context"
+
+type Client struct{}
+
+// GetUser returns the user.
+func (c *Client) GetUser(ctx context.Context, id string) (*User, error) {
+	return nil, nil
+}
+
+// APIGetUser returns the user by the API.
+func (c *Client) APIGetUser(ctx context.Context, id string) (*User, error) {
+	return nil, nil
+}
+
+type User struct{}
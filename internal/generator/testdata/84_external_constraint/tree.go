@@ -0,0 +1,26 @@
+package tree
+
+import (
+	"golang.org/x/exp/constraints"
+
+	num "github.com/acme/numeric"
+)
+
+// Tree is a sorted tree of the values.
+type Tree[K constraints.Ordered, V interface{ ~[]num.Real | num.Vector }] struct {
+	root *node[K, V]
+}
+
+type node[K constraints.Ordered, V interface{ ~[]num.Real | num.Vector }] struct {
+	key   K
+	value V
+}
+
+// Put stores the value under the key.
+func (t *Tree[K, V]) Put(key K, value V) {}
+
+// Get returns the value stored under the key.
+func (t *Tree[K, V]) Get(key K) (V, bool) {
+	var v V
+	return v, false
+}
@@ -0,0 +1,20 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	units "github.com/acme/units/v2"
+)
+
+type Client struct{}
+
+// Do sends the request.
+func (c *Client) Do(ctx context.Context, req *http.Request) (*http.Response, error) {
+	return nil, nil
+}
+
+// Limit returns the rate limit of the client.
+func (c *Client) Limit() units.Rate {
+	return 0
+}
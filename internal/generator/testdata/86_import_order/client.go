@@ -0,0 +1,24 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	apiv1 "github.com/acme/api/v1"
+	"github.com/acme/cache"
+	typesv1 "github.com/acme/types/v1"
+)
+
+type Client struct{}
+
+func (c *Client) Fetch(ctx context.Context, id typesv1.ID) (*apiv1.Item, error) {
+	return nil, nil
+}
+
+func (c *Client) Cache() *cache.Store {
+	return nil
+}
+
+func (c *Client) Timeout() time.Duration {
+	return 0
+}
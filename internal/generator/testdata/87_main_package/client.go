@@ -0,0 +1,20 @@
+package api
+
+import (
+	"context"
+	"io"
+)
+
+type Client struct{}
+
+// Get returns the item.
+func (c *Client) Get(ctx context.Context, id string) (*Item, error) {
+	return nil, nil
+}
+
+// Download streams the file.
+func (c *Client) Download(ctx context.Context, name string) (io.ReadCloser, error) {
+	return nil, nil
+}
+
+type Item struct{}
@@ -0,0 +1,18 @@
+package client
+
+type Client struct{}
+
+// Get returns the value of the key.
+func (c *Client) Get(key string) (string, error) {
+	return "", nil
+}
+
+// Getter is not the method name, the doc is kept.
+func (c *Client) GetAll() ([]string, error) {
+	return nil, nil
+}
+
+// Set stores the value, Get returns it then.
+func (c *Client) Set(key, value string) error {
+	return nil
+}
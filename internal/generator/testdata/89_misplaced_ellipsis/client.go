@@ -0,0 +1,11 @@
+package client
+
+type Client struct{}
+
+func (c *Client) Get(key string) (string, error) {
+	return "", nil
+}
+
+func (c *Client) Do(args ...string, n int) error {
+	return nil
+}
@@ -0,0 +1,15 @@
+package api
+
+import (
+	"example.com/models"
+	"example.com/store"
+)
+
+type Client struct {
+	*store.Cache[models.Key, *models.Value]
+}
+
+// Close releases the client.
+func (c *Client) Close() error {
+	return nil
+}
@@ -0,0 +1,5 @@
+package models
+
+type Key string
+
+type Value struct{}
@@ -0,0 +1,22 @@
+package store
+
+type Cache[K comparable, V any] struct{}
+
+// Get returns the value of the key.
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	var value V
+	return value, false
+}
+
+// Set stores the value of the key.
+func (c *Cache[Key, Value]) Set(key Key, value Value) {}
+
+// Items returns the values by the keys.
+func (c *Cache[K, V]) Items() map[K][]V {
+	return nil
+}
+
+// Len returns the number of the keys.
+func (c *Cache[_, _]) Len() int {
+	return 0
+}
@@ -0,0 +1,18 @@
+package api
+
+const BlockSize = 16
+
+type Client struct{}
+
+// Hash returns the checksum of the data.
+func (c *Client) Hash(data []byte) [32]byte {
+	return [32]byte{}
+}
+
+// Arr takes the fixed size arrays.
+func (c *Client) Arr(a [4]string, b ...[2]int) {}
+
+// Block returns the first block.
+func (c *Client) Block() ([BlockSize]byte, error) {
+	return [BlockSize]byte{}, nil
+}
@@ -1,8 +1,12 @@
 package generator
 
 import (
+	"errors"
 	"fmt"
 	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
 	"strings"
 )
 
@@ -16,6 +20,11 @@ const (
 	TypeKindMap       = "map"
 	TypeKindInterface = "interface"
 	TypeKindChan      = "chan"
+	TypeKindStruct    = "struct"
+	TypeKindInstance  = "instance"
+	TypeKindUnion     = "union"
+	TypeKindApprox    = "approx"
+	TypeKindParen     = "paren"
 )
 
 type Type struct {
@@ -24,148 +33,522 @@ type Type struct {
 	Name    string
 	Kind    string
 
+	// ImportPath of the Package, if known
+	ImportPath string
+
 	// For function parameters only
 	Results []*Param
 	Params  []*Param
 
+	// For structs, and the methods of interfaces, which
+	// types are the func ones of their signatures
+	Fields []*Param
+
+	// For interfaces only, the embedded ones and the type sets
+	// of the constraints: interface{ ~int | pkg.ID }
+	Elems []*Type
+
+	// For instantiated generic types only
+	TypeArgs []*Type
+
+	// For constraint unions only
+	Terms []*Type
+
+	// For arrays only, the length of [N]T, nil for the slices
+	arrayLen *Type
+
 	// For maps only
 	mapKeyType *Type
 	mapValType *Type
 
 	// For channels only
 	chanDir ast.ChanDir
+
+	// For the results of the stub methods only, see zeroValue
+	zero string
 }
 
 func (t *Type) String() string {
+	var b strings.Builder
+	t.writeTo(&b)
+	return b.String()
+}
+
+// writeTo renders the type into the builder, the nested
+// types share it instead of being rendered on their own.
+func (t *Type) writeTo(b *strings.Builder) {
 	switch t.Kind {
 	case TypeKindStar:
-		return "*" + t.Child.String()
+		b.WriteString("*")
+		t.Child.writeTo(b)
 	case TypeKindIdent:
-		if t.Package == "" {
-			return t.Name
+		if t.Package != "" {
+			b.WriteString(t.Package)
+			b.WriteString(".")
 		}
-		return fmt.Sprintf("%s.%s", t.Package, t.Name)
+		b.WriteString(t.Name)
 	case TypeKindEllipsis:
-		return fmt.Sprintf("...%s", t.Child.String())
+		b.WriteString("...")
+		t.Child.writeTo(b)
 	case TypeKindArray:
-		return fmt.Sprintf("[]%s", t.Child.String())
+		b.WriteString("[")
+		if t.arrayLen != nil {
+			t.arrayLen.writeTo(b)
+		}
+		b.WriteString("]")
+		t.Child.writeTo(b)
 	case TypeKindMap:
-		return fmt.Sprintf("map[%s]%s", t.mapKeyType.String(), t.mapValType.String())
+		b.WriteString("map[")
+		t.mapKeyType.writeTo(b)
+		b.WriteString("]")
+		t.mapValType.writeTo(b)
 	case TypeKindSelector:
-		return fmt.Sprintf("%s.%s", t.Package, t.Name)
+		b.WriteString(t.Package)
+		b.WriteString(".")
+		b.WriteString(t.Name)
 	case TypeKindInterface:
-		return "interface{}"
-	case TypeKindFunc:
-		params := make([]string, len(t.Params))
-		for i, p := range t.Params {
-			params[i] = p.String()
+		if t.empty() {
+			b.WriteString("interface{}")
+			return
 		}
 
-		results := make([]string, len(t.Results))
-		for i, r := range t.Results {
-			results[i] = r.String()
+		b.WriteString("interface{ ")
+		for i, f := range t.Fields {
+			if i > 0 {
+				b.WriteString("; ")
+			}
+			b.WriteString(f.Name)
+			writeSignature(b, f.Type.Params, f.Type.Results)
 		}
-
-		resultsTemplate := "%s"
-		if len(results) > 1 {
-			resultsTemplate = "(%s)"
+		for i, e := range t.Elems {
+			if i > 0 || len(t.Fields) > 0 {
+				b.WriteString("; ")
+			}
+			e.writeTo(b)
+		}
+		b.WriteString(" }")
+	case TypeKindStruct:
+		if len(t.Fields) == 0 {
+			b.WriteString("struct{}")
+			return
 		}
 
-		return fmt.Sprintf(
-			"func(%s) "+resultsTemplate,
-			strings.Join(params, ", "),
-			strings.Join(results, ", "),
-		)
+		b.WriteString("struct{ ")
+		writeParams(b, t.Fields, "; ")
+		b.WriteString(" }")
+	case TypeKindFunc:
+		b.WriteString("func")
+		writeSignature(b, t.Params, t.Results)
+	case TypeKindInstance:
+		t.Child.writeTo(b)
+		b.WriteString("[")
+		writeTypes(b, t.TypeArgs, ", ")
+		b.WriteString("]")
+	case TypeKindUnion:
+		writeTypes(b, t.Terms, " | ")
+	case TypeKindApprox:
+		b.WriteString("~")
+		t.Child.writeTo(b)
+	case TypeKindParen:
+		b.WriteString("(")
+		t.Child.writeTo(b)
+		b.WriteString(")")
 	case TypeKindChan:
-		format := "chan %s"
 		switch t.chanDir {
 		case ast.RECV:
-			format = "<-chan %s"
+			b.WriteString("<-chan ")
 		case ast.SEND:
-			format = "chan<- %s"
+			b.WriteString("chan<- ")
+		default:
+			b.WriteString("chan ")
+		}
+
+		t.Child.writeTo(b)
+	}
+}
+
+// writeSignature renders the parameters and the results the way gofmt
+// does: Get(), Get() error, Get() (int, error).
+func writeSignature(b *strings.Builder, params, results []*Param) {
+	b.WriteString("(")
+	writeParams(b, params, ", ")
+	b.WriteString(")")
+
+	switch {
+	case len(results) == 0:
+	case len(results) > 1 || results[0].Name != "":
+		// a named result can't go without the parentheses
+		b.WriteString(" (")
+		writeParams(b, results, ", ")
+		b.WriteString(")")
+	default:
+		b.WriteString(" ")
+		results[0].writeTo(b)
+	}
+}
+
+func writeTypes(b *strings.Builder, types []*Type, sep string) {
+	for i, t := range types {
+		if i > 0 {
+			b.WriteString(sep)
+		}
+		t.writeTo(b)
+	}
+}
+
+func writeParams(b *strings.Builder, params []*Param, sep string) {
+	for i, p := range params {
+		if i > 0 {
+			b.WriteString(sep)
 		}
+		p.writeTo(b)
+	}
+}
 
-		return fmt.Sprintf(format, t.Child.String())
+// UnsupportedTypeError is returned for the type expressions
+// which can't be reproduced in the interface.
+type UnsupportedTypeError struct {
+	Node ast.Node
+}
+
+func (e *UnsupportedTypeError) Error() string {
+	if sel, ok := e.Node.(*ast.SelectorExpr); ok {
+		return fmt.Sprintf("unsupported qualified identifier %s, the qualifier has to be a package name", types.ExprString(sel))
 	}
-	return ""
+	return fmt.Sprintf("unsupported type expression %T", e.Node)
+}
+
+// RenderedTypeError is returned for the type expressions which are rendered
+// as a different type, the struct wouldn't implement the interface then.
+type RenderedTypeError struct {
+	Node     ast.Expr
+	Rendered string
+}
+
+func (e *RenderedTypeError) Error() string {
+	return fmt.Sprintf("type %s is rendered as %s", types.ExprString(e.Node), e.Rendered)
+}
+
+// positionError prefixes the unsupported and the rendered type errors with
+// the position of the node, so the offending declaration is easy to find.
+func positionError(fset *token.FileSet, err error) error {
+	var (
+		unsupported *UnsupportedTypeError
+		rendered    *RenderedTypeError
+		node        ast.Node
+	)
+
+	switch {
+	case errors.As(err, &unsupported):
+		node = unsupported.Node
+	case errors.As(err, &rendered):
+		node = rendered.Node
+	default:
+		return err
+	}
+
+	return fmt.Errorf("%s: %v, consider declaring a named type for it", fset.Position(node.Pos()), err)
+}
+
+// checkRendered compares the type, rendered without the package qualifying
+// the types it declares, with the expression it is parsed from, both printed
+// with go/printer. The names of the parameters sharing a type are split, so
+// func(a, b int) is the same as func(a int, b int), and the tags, which are
+// copied as they are, left out.
+func checkRendered(expr ast.Expr, t *Type) error {
+	unqualified := t.clone()
+	unqualified.walk(func(t *Type) {
+		if t.Kind == TypeKindIdent {
+			t.Package = ""
+		}
+	})
+
+	source, rendered := types.ExprString(expr), unqualified.String()
+
+	// ...T is not an expression on its own
+	if _, ok := expr.(*ast.Ellipsis); ok {
+		source, rendered = "func("+source+")", "func("+rendered+")"
+	}
+
+	want, err := printType(source)
+	if err != nil {
+		return err
+	}
+
+	if got, err := printType(rendered); err != nil || got != want {
+		return &RenderedTypeError{Node: expr, Rendered: t.String()}
+	}
+
+	return nil
+}
+
+// printType parses the type and prints it back with the fields split
+// and without the tags.
+func printType(src string) (string, error) {
+	expr, err := parser.ParseExpr(src)
+	if err != nil {
+		return "", err
+	}
+
+	ast.Inspect(expr, func(node ast.Node) bool {
+		if list, ok := node.(*ast.FieldList); ok {
+			list.List = splitFields(list.List)
+		}
+		return true
+	})
+
+	return formatNode(token.NewFileSet(), expr), nil
+}
+
+func splitFields(fields []*ast.Field) []*ast.Field {
+	split := make([]*ast.Field, 0, len(fields))
+
+	for _, field := range fields {
+		if len(field.Names) < 2 {
+			split = append(split, &ast.Field{Names: field.Names, Type: field.Type})
+			continue
+		}
+		for _, name := range field.Names {
+			split = append(split, &ast.Field{Names: []*ast.Ident{name}, Type: field.Type})
+		}
+	}
+
+	return split
+}
+
+// isPredeclaredType reports whether the name is
+// one of the builtin types: error, any, int and the like.
+func isPredeclaredType(name string) bool {
+	_, ok := types.Universe.Lookup(name).(*types.TypeName)
+	return ok
 }
 
 func ParseType(
 	node ast.Node,
 	typesMap map[string]struct{},
 	sourcePackageName string,
-) *Type {
+) (*Type, error) {
 	formatPackage := func(pkg, typeName string) string {
 		if pkg != "" {
 			return ""
 		}
+		// error or int are never the types of the package, the ones it
+		// declares are unexported and handled as such, see replaceUnexported
+		if isPredeclaredType(typeName) {
+			return pkg
+		}
 		if _, ok := typesMap[typeName]; ok {
 			return sourcePackageName
 		}
 		return pkg
 	}
 
+	parse := func(node ast.Node) (*Type, error) {
+		return ParseType(node, typesMap, sourcePackageName)
+	}
+
+	// wraps the types composed of a single one
+	withChild := func(kind string, node ast.Node) (*Type, error) {
+		child, err := parse(node)
+		if err != nil {
+			return nil, err
+		}
+		return &Type{Kind: kind, Child: child}, nil
+	}
+
 	switch paramType := node.(type) {
 	case *ast.SelectorExpr:
+		// a type can only be qualified with a package name,
+		// outer.inner.Type or (pkg).Type don't compile
+		pkg, ok := paramType.X.(*ast.Ident)
+		if !ok {
+			return nil, &UnsupportedTypeError{Node: node}
+		}
+
 		return &Type{
 			Name:    paramType.Sel.Name,
-			Package: identName(paramType.X),
+			Package: pkg.Name,
 			Kind:    TypeKindSelector,
-		}
+		}, nil
 	case *ast.Ident:
 		return &Type{
 			Name:    identName(paramType),
 			Package: formatPackage("", identName(paramType)),
 			Kind:    TypeKindIdent,
-		}
+		}, nil
 	case *ast.Ellipsis:
-		return &Type{
-			Child: ParseType(paramType.Elt, typesMap, sourcePackageName),
-			Kind:  TypeKindEllipsis,
-		}
+		return withChild(TypeKindEllipsis, paramType.Elt)
 	case *ast.StarExpr:
-		return &Type{
-			Child: ParseType(paramType.X, typesMap, sourcePackageName),
-			Kind:  TypeKindStar,
-		}
+		return withChild(TypeKindStar, paramType.X)
 	case *ast.FuncType:
+		params, err := ParseMany(extractList(paramType.Params), typesMap, sourcePackageName)
+		if err != nil {
+			return nil, err
+		}
+		results, err := ParseMany(extractList(paramType.Results), typesMap, sourcePackageName)
+		if err != nil {
+			return nil, err
+		}
+
 		return &Type{
-			Params:  ParseMany(extractList(paramType.Params), typesMap, sourcePackageName),
-			Results: ParseMany(extractList(paramType.Results), typesMap, sourcePackageName),
+			Params:  params,
+			Results: results,
 			Kind:    TypeKindFunc,
-		}
+		}, nil
 	case *ast.ArrayType:
-		return &Type{
-			Child: ParseType(paramType.Elt, typesMap, sourcePackageName),
-			Kind:  TypeKindArray,
+		t, err := withChild(TypeKindArray, paramType.Elt)
+		if err != nil || paramType.Len == nil {
+			return t, err
+		}
+
+		// a constant, [32]byte, [N]byte or [pkg.N]byte, the N of a
+		// signature can only be declared in the package
+		switch length := paramType.Len.(type) {
+		case *ast.BasicLit:
+			t.arrayLen = &Type{Name: length.Value, Kind: TypeKindIdent}
+		case *ast.Ident:
+			t.arrayLen = &Type{Name: length.Name, Package: sourcePackageName, Kind: TypeKindIdent}
+		case *ast.SelectorExpr:
+			if t.arrayLen, err = parse(length); err != nil {
+				return nil, err
+			}
+		default:
+			// the whole array, best effort replaces it with any
+			return nil, &UnsupportedTypeError{Node: paramType}
 		}
+
+		return t, nil
 	case *ast.MapType:
+		key, err := parse(paramType.Key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := parse(paramType.Value)
+		if err != nil {
+			return nil, err
+		}
+
 		return &Type{
 			Kind:       TypeKindMap,
-			mapKeyType: ParseType(paramType.Key, typesMap, sourcePackageName),
-			mapValType: ParseType(paramType.Value, typesMap, sourcePackageName),
-		}
+			mapKeyType: key,
+			mapValType: value,
+		}, nil
 	case *ast.InterfaceType:
-		return &Type{
-			Kind: TypeKindInterface,
+		t := &Type{Kind: TypeKindInterface}
+
+		for _, field := range extractList(paramType.Methods) {
+			if len(field.Names) == 0 {
+				elem, err := parse(field.Type)
+				if err != nil {
+					return nil, err
+				}
+				t.Elems = append(t.Elems, elem)
+				continue
+			}
+
+			signature, err := parse(field.Type)
+			if err != nil {
+				return nil, err
+			}
+			t.Fields = append(t.Fields, &Param{Name: field.Names[0].Name, Type: signature})
 		}
-	case *ast.ChanType:
+
+		return t, nil
+	case *ast.StructType:
+		fields, err := ParseMany(extractList(paramType.Fields), typesMap, sourcePackageName)
+		if err != nil {
+			return nil, err
+		}
+
 		return &Type{
-			Kind:    TypeKindChan,
-			Child:   ParseType(paramType.Value, typesMap, sourcePackageName),
-			chanDir: paramType.Dir,
+			Kind:   TypeKindStruct,
+			Fields: fields,
+		}, nil
+	case *ast.ChanType:
+		t, err := withChild(TypeKindChan, paramType.Value)
+		if err != nil {
+			return nil, err
 		}
+
+		t.chanDir = paramType.Dir
+
+		return t, nil
+	case *ast.IndexExpr:
+		return parseInstance(paramType.X, []ast.Expr{paramType.Index}, parse)
+	case *ast.IndexListExpr:
+		return parseInstance(paramType.X, paramType.Indices, parse)
+	case *ast.BinaryExpr:
+		// the other operators make constant expressions, not types
+		if paramType.Op != token.OR {
+			return nil, &UnsupportedTypeError{Node: node}
+		}
+
+		// int | ~string | pkg.ID, the terms are nested from the left
+		x, err := parse(paramType.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := parse(paramType.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		union := &Type{Kind: TypeKindUnion}
+		if x.Kind == TypeKindUnion {
+			union.Terms = x.Terms
+		} else {
+			union.Terms = []*Type{x}
+		}
+		union.Terms = append(union.Terms, y)
+
+		return union, nil
+	case *ast.UnaryExpr:
+		if paramType.Op != token.TILDE {
+			return nil, &UnsupportedTypeError{Node: node}
+		}
+		return withChild(TypeKindApprox, paramType.X)
+	case *ast.ParenExpr:
+		// kept, chan (<-chan int) is not chan <-chan int
+		return withChild(TypeKindParen, paramType.X)
 	default:
-		panic(fmt.Sprintf("unhandled type %T", node))
+		return nil, &UnsupportedTypeError{Node: node}
+	}
+}
+
+func parseInstance(x ast.Expr, indices []ast.Expr, parse func(ast.Node) (*Type, error)) (*Type, error) {
+	generic, err := parse(x)
+	if err != nil {
+		return nil, err
 	}
+
+	typeArgs := make([]*Type, len(indices))
+	for i, index := range indices {
+		if typeArgs[i], err = parse(index); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Type{
+		Kind:     TypeKindInstance,
+		Child:    generic,
+		TypeArgs: typeArgs,
+	}, nil
 }
 
 func parseTypesFromFile(fileAst *ast.File) []string {
+	return parseDeclaredTypes(fileAst, true)
+}
+
+func parseUnexportedTypesFromFile(fileAst *ast.File) []string {
+	return parseDeclaredTypes(fileAst, false)
+}
+
+func parseDeclaredTypes(fileAst *ast.File, exported bool) []string {
 	var types []string
 
 	ast.Inspect(fileAst, func(node ast.Node) bool {
 		ts, ok := node.(*ast.TypeSpec)
-		if !ok || !ts.Name.IsExported() {
+		if !ok || ts.Name.IsExported() != exported {
 			return true
 		}
 
@@ -176,3 +559,91 @@ func parseTypesFromFile(fileAst *ast.File) []string {
 
 	return types
 }
+
+// empty reports whether the type is interface{}, which is any.
+func (t *Type) empty() bool {
+	return t.Kind == TypeKindInterface && len(t.Fields) == 0 && len(t.Elems) == 0
+}
+
+// walk calls fn for the type and all the types it is composed of.
+func (t *Type) walk(fn func(*Type)) {
+	if t == nil {
+		return
+	}
+
+	fn(t)
+
+	t.Child.walk(fn)
+	t.arrayLen.walk(fn)
+	t.mapKeyType.walk(fn)
+	t.mapValType.walk(fn)
+
+	for _, p := range t.Params {
+		p.Type.walk(fn)
+	}
+	for _, r := range t.Results {
+		r.Type.walk(fn)
+	}
+	for _, f := range t.Fields {
+		f.Type.walk(fn)
+	}
+	for _, a := range t.TypeArgs {
+		a.walk(fn)
+	}
+	for _, term := range t.Terms {
+		term.walk(fn)
+	}
+	for _, e := range t.Elems {
+		e.walk(fn)
+	}
+}
+
+// clone copies the type along with the nested ones, so
+// the copy can be changed without changing the original.
+func (t *Type) clone() *Type {
+	if t == nil {
+		return nil
+	}
+
+	c := *t
+	c.Child = t.Child.clone()
+	c.arrayLen = t.arrayLen.clone()
+	c.mapKeyType = t.mapKeyType.clone()
+	c.mapValType = t.mapValType.clone()
+	c.Params = cloneParams(t.Params)
+	c.Results = cloneParams(t.Results)
+	c.Fields = cloneParams(t.Fields)
+	c.Elems = cloneTypes(t.Elems)
+	c.TypeArgs = cloneTypes(t.TypeArgs)
+	c.Terms = cloneTypes(t.Terms)
+
+	return &c
+}
+
+func cloneParams(params []*Param) []*Param {
+	if params == nil {
+		return nil
+	}
+
+	cloned := make([]*Param, len(params))
+	for i, p := range params {
+		c := *p
+		c.Type = p.Type.clone()
+		cloned[i] = &c
+	}
+
+	return cloned
+}
+
+func cloneTypes(types []*Type) []*Type {
+	if types == nil {
+		return nil
+	}
+
+	cloned := make([]*Type, len(types))
+	for i, t := range types {
+		cloned[i] = t.clone()
+	}
+
+	return cloned
+}
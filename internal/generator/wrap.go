@@ -0,0 +1,99 @@
+package generator
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// wrapComment rewraps the prose paragraphs of the line comments having a
+// line longer than the width, the "// " counted. The code blocks and the
+// lists, which are indented, the directives, //go:generate and the like,
+// and the Deprecated paragraphs are kept as they are, so are the block
+// comments.
+func wrapComment(comment string, width int) string {
+	if width <= 0 || !strings.HasPrefix(comment, "//") {
+		return comment
+	}
+
+	lines := strings.Split(strings.TrimSuffix(comment, "\n"), "\n")
+
+	var (
+		b          strings.Builder
+		paragraph  []string
+		deprecated bool
+	)
+
+	for _, line := range lines {
+		if isProse(line) && strings.HasPrefix(line, "// Deprecated:") {
+			deprecated = true
+		}
+
+		if !isProse(line) || deprecated {
+			writeParagraph(&b, paragraph, width)
+			paragraph = nil
+
+			b.WriteString(line)
+			b.WriteString("\n")
+
+			// a Deprecated paragraph ends with a blank line
+			deprecated = deprecated && isProse(line)
+			continue
+		}
+
+		paragraph = append(paragraph, line)
+	}
+
+	writeParagraph(&b, paragraph, width)
+
+	return b.String()
+}
+
+// isProse reports whether the line is a text one: "// " followed by
+// something but a space, gofmt indents the code blocks and the lists.
+func isProse(line string) bool {
+	text := strings.TrimPrefix(line, "// ")
+	return text != line && text != "" && text[0] != ' ' && text[0] != '\t' && !strings.HasPrefix(text, "# ")
+}
+
+// writeParagraph writes the lines as they are unless one of
+// them is longer than the width, the words are reflowed then.
+func writeParagraph(b *strings.Builder, lines []string, width int) {
+	if len(lines) == 0 {
+		return
+	}
+
+	long := false
+	for _, line := range lines {
+		if utf8.RuneCountInString(line) > width {
+			long = true
+			break
+		}
+	}
+
+	if !long {
+		for _, line := range lines {
+			b.WriteString(line)
+			b.WriteString("\n")
+		}
+		return
+	}
+
+	var words []string
+	for _, line := range lines {
+		words = append(words, strings.Fields(strings.TrimPrefix(line, "//"))...)
+	}
+
+	line := "//"
+	for _, word := range words {
+		// a word longer than the width gets a line of its own
+		if line != "//" && utf8.RuneCountInString(line)+1+utf8.RuneCountInString(word) > width {
+			b.WriteString(line)
+			b.WriteString("\n")
+			line = "//"
+		}
+		line += " " + word
+	}
+
+	b.WriteString(line)
+	b.WriteString("\n")
+}
@@ -0,0 +1,112 @@
+package gomodule
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// Open makes the module read from the given location instead of the module
+// cache. It is either a directory the module is extracted to or a module zip
+// archive, like the ones in the cache/download directory of the module cache,
+// which gets extracted to a temporary directory removed by the returned func.
+func (p *parser) Open(m *Module, location string) (func(), error) {
+	if !strings.HasSuffix(location, ".zip") {
+		m.root = location
+		return func() {}, nil
+	}
+
+	dir, err := afero.TempDir(p.fs, "", "ifacemaker-")
+	if err != nil {
+		return nil, err
+	}
+
+	cleanup := func() {
+		_ = p.fs.RemoveAll(dir) //nolint:errcheck
+	}
+
+	if err := p.extract(m, location, dir); err != nil {
+		cleanup()
+		return nil, fmt.Errorf("extracting %s: %v", location, err)
+	}
+
+	m.root = dir
+
+	return cleanup, nil
+}
+
+// extract writes the files of the module zip archive to the directory.
+// The archive stores them under the module@version/ prefix, the version
+// has to be the requested one if any.
+func (p *parser) extract(m *Module, archive, dir string) error {
+	content, err := afero.ReadFile(p.fs, archive)
+	if err != nil {
+		return err
+	}
+
+	r, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return err
+	}
+
+	prefix := m.Path() + "@"
+
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+
+		if !strings.HasPrefix(f.Name, prefix) {
+			return fmt.Errorf("file %s doesn't belong to module %s", f.Name, m.Path())
+		}
+
+		// github.com/acme/lib@v1.2.0/pkg/lib.go -> pkg/lib.go
+		version, name, _ := strings.Cut(strings.TrimPrefix(f.Name, prefix), "/")
+		if m.Ver != nil && version != "v"+m.Ver.String() {
+			return fmt.Errorf("file %s doesn't belong to version v%s of module %s", f.Name, m.Ver, m.Path())
+		}
+
+		name = path.Clean(name)
+		if name == "." || name == ".." || strings.HasPrefix(name, "../") {
+			return fmt.Errorf("invalid file name %s", f.Name)
+		}
+
+		if err := p.extractFile(f, filepath.Join(dir, filepath.FromSlash(name))); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *parser) extractFile(f *zip.File, filename string) error {
+	src, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	if err := p.fs.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+
+	dst, err := p.fs.Create(filename)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(dst, src); err != nil {
+		_ = dst.Close() //nolint:errcheck
+		return err
+	}
+
+	return dst.Close()
+}
+
+var Open = defaultParser.Open
@@ -0,0 +1,141 @@
+package gomodule
+
+import (
+	"archive/zip"
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/Masterminds/semver"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpen(t *testing.T) {
+	// a module cache prepared by CI with nothing in GOMODCACHE
+	cache := "/ci/modcache"
+
+	newModule := func() *Module {
+		return &Module{
+			Name: "github.com/acme/lib",
+			Base: "lib",
+			Dir:  "github.com/acme",
+			Ver:  semver.MustParse("v1.2.0"),
+
+			gomodcache: func() string { return "/path/to/modcache" },
+			goroot:     func() string { return "/path/to/goroot" },
+		}
+	}
+
+	t.Run("extracted directory", func(t *testing.T) {
+		parser := newParser()
+		parser.fs = afero.NewMemMapFs()
+		dir := filepath.Join(cache, "github.com/acme/lib@v1.2.0")
+		_ = afero.WriteFile(parser.fs, filepath.Join(dir, "go.mod"), []byte("module github.com/acme/lib\n\ngo 1.20\n"), 0644) //nolint:errcheck
+		module := newModule()
+
+		// act
+		cleanup, err := parser.Open(module, dir)
+
+		// assert
+		require.NoError(t, err)
+		defer cleanup()
+		require.Equal(t, filepath.Join(dir, "client"), module.Directory("client"))
+
+		goVersion, err := parser.GoVersion(module)
+		require.NoError(t, err)
+		require.Equal(t, "1.20", goVersion)
+	})
+
+	t.Run("zip archive", func(t *testing.T) {
+		parser := newParser()
+		parser.fs = afero.NewMemMapFs()
+		archive := filepath.Join(cache, "cache/download/github.com/acme/lib/@v/v1.2.0.zip")
+		_ = afero.WriteFile(parser.fs, archive, testZip(t, map[string]string{ //nolint:errcheck
+			"github.com/acme/lib@v1.2.0/go.mod":           "module github.com/acme/lib\n\ngo 1.20\n",
+			"github.com/acme/lib@v1.2.0/client/client.go": "package client\n",
+		}), 0644)
+		module := newModule()
+
+		// act
+		cleanup, err := parser.Open(module, archive)
+
+		// assert
+		require.NoError(t, err)
+		content, err := afero.ReadFile(parser.fs, filepath.Join(module.Directory("client"), "client.go"))
+		require.NoError(t, err)
+		require.Equal(t, "package client\n", string(content))
+
+		goVersion, err := parser.GoVersion(module)
+		require.NoError(t, err)
+		require.Equal(t, "1.20", goVersion)
+
+		cleanup()
+		exists, err := afero.DirExists(parser.fs, module.Directory(""))
+		require.NoError(t, err)
+		require.False(t, exists)
+	})
+
+	t.Run("archive of another module", func(t *testing.T) {
+		parser := newParser()
+		parser.fs = afero.NewMemMapFs()
+		archive := filepath.Join(cache, "other.zip")
+		_ = afero.WriteFile(parser.fs, archive, testZip(t, map[string]string{ //nolint:errcheck
+			"github.com/acme/other@v1.0.0/go.mod": "module github.com/acme/other\n",
+		}), 0644)
+
+		// act
+		_, err := parser.Open(newModule(), archive)
+
+		// assert
+		require.EqualError(t, err, "extracting /ci/modcache/other.zip: file github.com/acme/other@v1.0.0/go.mod doesn't belong to module github.com/acme/lib")
+	})
+
+	t.Run("archive of another version", func(t *testing.T) {
+		parser := newParser()
+		parser.fs = afero.NewMemMapFs()
+		archive := filepath.Join(cache, "cache/download/github.com/acme/lib/@v/v1.1.0.zip")
+		_ = afero.WriteFile(parser.fs, archive, testZip(t, map[string]string{ //nolint:errcheck
+			"github.com/acme/lib@v1.1.0/go.mod": "module github.com/acme/lib\n",
+		}), 0644)
+
+		// act
+		_, err := parser.Open(newModule(), archive)
+
+		// assert
+		require.EqualError(t, err, "extracting "+archive+": file github.com/acme/lib@v1.1.0/go.mod doesn't belong to version v1.2.0 of module github.com/acme/lib")
+	})
+
+	t.Run("path traversal", func(t *testing.T) {
+		parser := newParser()
+		parser.fs = afero.NewMemMapFs()
+		archive := filepath.Join(cache, "evil.zip")
+		_ = afero.WriteFile(parser.fs, archive, testZip(t, map[string]string{ //nolint:errcheck
+			"github.com/acme/lib@v1.2.0/../../escaped.go": "package escaped\n",
+		}), 0644)
+
+		// act
+		_, err := parser.Open(newModule(), archive)
+
+		// assert
+		require.Error(t, err)
+	})
+}
+
+func testZip(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+
+	for name, content := range files {
+		f, err := w.Create(name)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(content))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, w.Close())
+
+	return buf.Bytes()
+}
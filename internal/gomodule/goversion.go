@@ -0,0 +1,41 @@
+package gomodule
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+	"golang.org/x/mod/modfile"
+)
+
+// GoVersion returns the go directive of the module's go.mod file. It is empty
+// for the standard library and the modules which have no go.mod or directive.
+func (p *parser) GoVersion(m *Module) (string, error) {
+	if !m.IsThirdParty() {
+		return "", nil
+	}
+
+	goModPath := filepath.Join(m.Directory(""), "go.mod")
+	content, err := afero.ReadFile(p.fs, goModPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("reading %s: %v", goModPath, err)
+	}
+
+	goMod, err := modfile.ParseLax(goModPath, content, nil)
+	if err != nil {
+		return "", fmt.Errorf("parsing %s: %v", goModPath, err)
+	}
+
+	if goMod.Go == nil {
+		return "", nil
+	}
+
+	return goMod.Go.Version, nil
+}
+
+var GoVersion = defaultParser.GoVersion
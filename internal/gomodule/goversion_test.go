@@ -0,0 +1,50 @@
+package gomodule
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/Masterminds/semver"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoVersion(t *testing.T) {
+	modcache := "/path/to/modcache"
+
+	module := &Module{
+		Name: "github.com/acme/generics",
+		Base: "generics",
+		Dir:  "github.com/acme",
+		Ver:  semver.MustParse("v1.2.0"),
+
+		gomodcache: func() string { return modcache },
+		goroot:     func() string { return "/path/to/goroot" },
+	}
+	goMod := filepath.Join(modcache, "github.com/acme/generics@v1.2.0/go.mod")
+
+	t.Run("go directive", func(t *testing.T) {
+		parser := newParser()
+		parser.fs = afero.NewMemMapFs()
+		_ = afero.WriteFile(parser.fs, goMod, []byte("module github.com/acme/generics\n\ngo 1.21\n"), 0644) //nolint:errcheck
+
+		// act
+		got, err := parser.GoVersion(module)
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, "1.21", got)
+	})
+
+	t.Run("no go.mod", func(t *testing.T) {
+		parser := newParser()
+		parser.fs = afero.NewMemMapFs()
+
+		// act
+		got, err := parser.GoVersion(module)
+
+		// assert
+		require.NoError(t, err)
+		require.Empty(t, got)
+	})
+}
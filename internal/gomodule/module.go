@@ -19,6 +19,10 @@ type Module struct {
 	Dir  string
 	Ver  *semver.Version
 
+	// the directory the module is read from
+	// instead of the module cache, see Open
+	root string
+
 	// mocked in tests to be reproducible
 	gomodcache func() string
 	goroot     func() string
@@ -44,6 +48,10 @@ func (p Module) VersionDirectory() string {
 }
 
 func (p Module) Directory(modulePath string) string {
+	if p.root != "" {
+		return filepath.Join(p.root, modulePath)
+	}
+
 	if !p.IsThirdParty() {
 		return filepath.Join(p.goroot(), "src", p.Name)
 	}
@@ -71,7 +79,12 @@ func newParser() *parser {
 func (p *parser) Parse(modulePath, versionStr string) (*Module, error) {
 	// net/http
 	if !strings.Contains(modulePath, ".") {
-		return &Module{Name: modulePath}, nil
+		return &Module{
+			Name: modulePath,
+
+			goroot:     golang.GOROOT,
+			gomodcache: p.modcache,
+		}, nil
 	}
 
 	// github.com/mattermost/mattermost-server/v5@v5.39.3
@@ -137,7 +150,7 @@ func (p *parser) Parse(modulePath, versionStr string) (*Module, error) {
 		Ver:  version,
 
 		goroot:     golang.GOROOT,
-		gomodcache: golang.GOMODCACHE,
+		gomodcache: p.modcache,
 	}, nil
 }
 
@@ -147,4 +160,14 @@ func sortVersions(versions []*semver.Version) {
 	})
 }
 
-var Parse = newParser().Parse
+// defaultParser backs the package functions,
+// so they share the module cache location
+var defaultParser = newParser()
+
+// UseModCache makes the modules read from the directory instead
+// of GOMODCACHE, so the cache can be anywhere the build allows.
+func UseModCache(dir string) {
+	defaultParser.modcache = func() string { return dir }
+}
+
+var Parse = defaultParser.Parse
@@ -57,6 +57,30 @@ func TestParse(t *testing.T) {
 	}
 }
 
+func TestUseModCache(t *testing.T) {
+	cache := t.TempDir()
+	for _, d := range []string{"github.com/acme/lib@v1.2.0", "github.com/acme/lib@v1.3.0", "github.com/acme/dep@v0.1.0/pkg"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(cache, d), os.ModePerm))
+	}
+	goMod := []byte("module github.com/acme/lib\n\ngo 1.19\n\nrequire github.com/acme/dep v0.1.0\n")
+	require.NoError(t, os.WriteFile(filepath.Join(cache, "github.com/acme/lib@v1.3.0/go.mod"), goMod, 0644))
+
+	modcache := defaultParser.modcache
+	t.Cleanup(func() { defaultParser.modcache = modcache })
+
+	// act
+	UseModCache(cache)
+	got, err := Parse("github.com/acme/lib", "")
+	require.NoError(t, err)
+	dep, subdir, err := Resolve(got, "github.com/acme/dep/pkg")
+
+	// assert
+	require.NoError(t, err)
+	require.Equal(t, semver.MustParse("v1.3.0"), got.Ver)
+	require.Equal(t, filepath.Join(cache, "github.com/acme/lib@v1.3.0", "client"), got.Directory("client"))
+	require.Equal(t, filepath.Join(cache, "github.com/acme/dep@v0.1.0", "pkg"), dep.Directory(subdir))
+}
+
 func TestSortVersions(t *testing.T) {
 	cases := []struct {
 		given []*semver.Version
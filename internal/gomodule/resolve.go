@@ -0,0 +1,163 @@
+package gomodule
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/afero"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+)
+
+// Resolve finds a module providing the given import path. Versions are taken
+// from the go.mod file of the source module, its replace directives applied.
+// The second result is the package path relative to the root of the returned
+// module.
+func (p *parser) Resolve(source *Module, importPath string) (*Module, string, error) {
+	// net/http
+	if !strings.Contains(strings.Split(importPath, "/")[0], ".") {
+		m, err := p.Parse(importPath, "")
+		return m, "", err
+	}
+
+	if subdir, ok := trimModulePath(importPath, source.Path()); ok {
+		return source, subdir, nil
+	}
+
+	goModPath := filepath.Join(source.Directory(""), "go.mod")
+	content, err := afero.ReadFile(p.fs, goModPath)
+	if err != nil {
+		return nil, "", fmt.Errorf("resolving %s, reading %s: %v", importPath, goModPath, err)
+	}
+
+	goMod, err := modfile.ParseLax(goModPath, content, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("resolving %s, parsing %s: %v", importPath, goModPath, err)
+	}
+
+	var (
+		found   *modfile.Require
+		foundIn string
+	)
+
+	// the longest matching requirement wins, so nested
+	// modules are preferred over their parents
+	for _, r := range goMod.Require {
+		subdir, ok := trimModulePath(importPath, r.Mod.Path)
+		if !ok {
+			continue
+		}
+		if found == nil || len(r.Mod.Path) > len(found.Mod.Path) {
+			found = r
+			foundIn = subdir
+		}
+	}
+
+	if found == nil {
+		return nil, "", fmt.Errorf("unable to find a requirement for %s in %s", importPath, goModPath)
+	}
+
+	replaces, err := parseReplaces(goModPath, goMod.Syntax)
+	if err != nil {
+		return nil, "", fmt.Errorf("resolving %s, parsing %s: %v", importPath, goModPath, err)
+	}
+
+	replacement := findReplace(replaces, found.Mod)
+	if replacement != nil && replacement.New.Version != "" {
+		m, err := p.Parse(replacement.New.Path, replacement.New.Version)
+		if err != nil {
+			return nil, "", err
+		}
+		return m, foundIn, nil
+	}
+
+	m, err := p.Parse(found.Mod.Path, found.Mod.Version)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// a local directory, relative to the source module
+	if replacement != nil {
+		m.root = filepath.FromSlash(replacement.New.Path)
+		if !filepath.IsAbs(m.root) {
+			m.root = filepath.Join(source.Directory(""), m.root)
+		}
+	}
+
+	return m, foundIn, nil
+}
+
+// parseReplaces parses the replace directives of the go.mod file, which
+// ParseLax skips. The rest of the file is left lax, it may have directives
+// newer than the ones x/mod knows.
+func parseReplaces(goModPath string, syntax *modfile.FileSyntax) ([]*modfile.Replace, error) {
+	var b strings.Builder
+
+	for _, stmt := range syntax.Stmt {
+		switch stmt := stmt.(type) {
+		case *modfile.Line:
+			if stmt.Token[0] == "replace" {
+				b.WriteString(strings.Join(stmt.Token, " ") + "\n")
+			}
+		case *modfile.LineBlock:
+			if stmt.Token[0] == "replace" {
+				for _, line := range stmt.Line {
+					b.WriteString("replace " + strings.Join(line.Token, " ") + "\n")
+				}
+			}
+		}
+	}
+
+	if b.Len() == 0 {
+		return nil, nil
+	}
+
+	replaces, err := modfile.Parse(goModPath, []byte(b.String()), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return replaces.Replace, nil
+}
+
+// findReplace returns the replace directive applied to the module, the one
+// of its version wins over the one of every version, as go does.
+func findReplace(replaces []*modfile.Replace, mod module.Version) *modfile.Replace {
+	var found *modfile.Replace
+
+	for _, r := range replaces {
+		if r.Old.Path != mod.Path {
+			continue
+		}
+		if r.Old.Version == mod.Version {
+			return r
+		}
+		if r.Old.Version == "" {
+			found = r
+		}
+	}
+
+	return found
+}
+
+// Path returns an import path of the module root.
+func (p Module) Path() string {
+	if !p.IsThirdParty() || p.Ver == nil || !p.HasMajor() {
+		return p.Name
+	}
+	return p.Name + "/v" + strconv.Itoa(int(p.Ver.Major()))
+}
+
+func trimModulePath(importPath, modulePath string) (string, bool) {
+	if importPath == modulePath {
+		return "", true
+	}
+	if strings.HasPrefix(importPath, modulePath+"/") {
+		return strings.TrimPrefix(importPath, modulePath+"/"), true
+	}
+	return "", false
+}
+
+var Resolve = defaultParser.Resolve
@@ -0,0 +1,199 @@
+package gomodule
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/Masterminds/semver"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+const testGoMod = `module github.com/acme/service/v2
+
+go 1.19
+
+require (
+	github.com/hashicorp/vault v1.8.2
+	github.com/hashicorp/vault/api v1.3.0
+	github.com/mattermost/mattermost-server/v5 v5.39.3
+)
+`
+
+const testReplaceGoMod = `module github.com/acme/service/v2
+
+go 1.21
+
+toolchain go1.21.5
+
+require (
+	github.com/acme/legacy v1.4.0
+	github.com/acme/lib v1.0.0
+	github.com/acme/pinned v1.1.0
+	github.com/acme/vendored v1.0.0
+)
+
+replace (
+	github.com/acme/legacy => github.com/acme/legacy-fork v1.4.2
+	github.com/acme/lib => ../lib
+	github.com/acme/pinned v1.0.0 => github.com/acme/pinned v1.0.1
+	github.com/acme/vendored v1.0.0 => /src/vendored
+	github.com/acme/vendored => github.com/acme/vendored v1.0.2
+)
+`
+
+func TestResolve(t *testing.T) {
+	modcache := "/path/to/modcache"
+
+	source := &Module{
+		Name: "github.com/acme/service",
+		Base: "service",
+		Dir:  "github.com/acme/service",
+		Ver:  semver.MustParse("v2.1.0"),
+
+		gomodcache: func() string { return modcache },
+		goroot:     func() string { return "/path/to/goroot" },
+	}
+
+	cases := []struct {
+		name       string
+		importPath string
+		wantName   string
+		wantVer    string
+		wantSubdir string
+	}{
+		{
+			name:       "major version module",
+			importPath: "github.com/mattermost/mattermost-server/v5/model",
+			wantName:   "github.com/mattermost/mattermost-server",
+			wantVer:    "v5.39.3",
+			wantSubdir: "model",
+		},
+		{
+			name:       "nested module wins over its parent",
+			importPath: "github.com/hashicorp/vault/api",
+			wantName:   "github.com/hashicorp/vault/api",
+			wantVer:    "v1.3.0",
+			wantSubdir: "",
+		},
+		{
+			name:       "package of the parent module",
+			importPath: "github.com/hashicorp/vault/sdk/helper",
+			wantName:   "github.com/hashicorp/vault",
+			wantVer:    "v1.8.2",
+			wantSubdir: "sdk/helper",
+		},
+		{
+			name:       "package of the source module",
+			importPath: "github.com/acme/service/v2/internal/store",
+			wantName:   "github.com/acme/service",
+			wantVer:    "v2.1.0",
+			wantSubdir: "internal/store",
+		},
+		{
+			name:       "standard library",
+			importPath: "net/http",
+			wantName:   "net/http",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			parser := newParser()
+			parser.fs = afero.NewMemMapFs()
+			parser.modcache = func() string { return modcache }
+			goMod := filepath.Join(modcache, "github.com/acme/service/v2@v2.1.0/go.mod")
+			_ = afero.WriteFile(parser.fs, goMod, []byte(testGoMod), 0644) //nolint:errcheck
+
+			// act
+			got, subdir, err := parser.Resolve(source, tc.importPath)
+
+			// assert
+			require.NoError(t, err)
+			require.Equal(t, tc.wantName, got.Name)
+			require.Equal(t, tc.wantSubdir, subdir)
+			if tc.wantVer != "" {
+				require.Equal(t, semver.MustParse(tc.wantVer), got.Ver)
+			}
+		})
+	}
+
+	t.Run("unknown requirement", func(t *testing.T) {
+		parser := newParser()
+		parser.fs = afero.NewMemMapFs()
+		goMod := filepath.Join(modcache, "github.com/acme/service/v2@v2.1.0/go.mod")
+		_ = afero.WriteFile(parser.fs, goMod, []byte(testGoMod), 0644) //nolint:errcheck
+
+		// act
+		_, _, err := parser.Resolve(source, "github.com/unknown/pkg")
+
+		// assert
+		require.Error(t, err)
+	})
+
+	t.Run("replace directives", func(t *testing.T) {
+		cases := []struct {
+			name       string
+			importPath string
+			wantName   string
+			wantVer    string
+			wantDir    string
+		}{
+			{
+				name:       "module replacement",
+				importPath: "github.com/acme/legacy/client",
+				wantName:   "github.com/acme/legacy-fork",
+				wantVer:    "v1.4.2",
+				wantDir:    filepath.Join(modcache, "github.com/acme/legacy-fork@v1.4.2/client"),
+			},
+			{
+				name:       "relative local directory",
+				importPath: "github.com/acme/lib/store",
+				wantName:   "github.com/acme/lib",
+				wantVer:    "v1.0.0",
+				wantDir:    filepath.Join(modcache, "github.com/acme/service/lib/store"),
+			},
+			{
+				name:       "replacement of another version",
+				importPath: "github.com/acme/pinned",
+				wantName:   "github.com/acme/pinned",
+				wantVer:    "v1.1.0",
+				wantDir:    filepath.Join(modcache, "github.com/acme/pinned@v1.1.0"),
+			},
+			{
+				name:       "version replacement wins",
+				importPath: "github.com/acme/vendored/api",
+				wantName:   "github.com/acme/vendored",
+				wantVer:    "v1.0.0",
+				wantDir:    "/src/vendored/api",
+			},
+		}
+
+		for _, tc := range cases {
+			tc := tc
+
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				parser := newParser()
+				parser.fs = afero.NewMemMapFs()
+				parser.modcache = func() string { return modcache }
+				goMod := filepath.Join(modcache, "github.com/acme/service/v2@v2.1.0/go.mod")
+				_ = afero.WriteFile(parser.fs, goMod, []byte(testReplaceGoMod), 0644) //nolint:errcheck
+
+				// act
+				got, subdir, err := parser.Resolve(source, tc.importPath)
+
+				// assert
+				require.NoError(t, err)
+				require.Equal(t, tc.wantName, got.Name)
+				require.Equal(t, semver.MustParse(tc.wantVer), got.Ver)
+				require.Equal(t, tc.wantDir, got.Directory(subdir))
+			})
+		}
+	})
+}